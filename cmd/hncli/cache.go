@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/spf13/cobra"
+)
+
+// openCache returns the on-disk response cache configured by --cache-ttl.
+func openCache() (*api.Cache, error) {
+	dir, err := api.DefaultCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("locating cache directory: %w", err)
+	}
+	return api.NewCache(dir, cacheTTL), nil
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect or clean the on-disk response cache",
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache location, size and freshness",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := openCache()
+		if err != nil {
+			return err
+		}
+		st, err := cache.Stats()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Directory: %s\n", st.Dir)
+		fmt.Printf("Entries:   %d (%d expired)\n", st.Entries, st.Expired)
+		fmt.Printf("Size:      %s\n", humanBytes(st.Bytes))
+		if st.Entries > 0 {
+			fmt.Printf("Oldest:    %s\n", st.Oldest.Format(time.RFC1123))
+			fmt.Printf("Newest:    %s\n", st.Newest.Format(time.RFC1123))
+		}
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached response",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := openCache()
+		if err != nil {
+			return err
+		}
+		n, err := cache.Clear()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d entries from %s\n", n, cache.Dir())
+		return nil
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired cached responses",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := openCache()
+		if err != nil {
+			return err
+		}
+		n, err := cache.Prune()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d expired entries from %s\n", n, cache.Dir())
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheStatsCmd, cacheClearCmd, cachePruneCmd)
+}
+
+// humanBytes formats n as a size in B, KiB, MiB or GiB.
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 2; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMG"[exp])
+}
@@ -0,0 +1,548 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hexadecimoose/hncli/internal/ui"
+	"github.com/hexadecimoose/hncli/internal/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Settings a config file may hold besides the global flags, with their
+// defaults.
+var configOnly = map[string]string{
+	"feed": "top", // feed bare hncli opens on
+	"open": "",    // command for opening links, like HNCLI_OPEN
+}
+
+// flagEnv maps flags to the environment variables that stand in for them.
+// A variable that is set takes precedence over the config file.
+var flagEnv = map[string]string{
+	"api-url":     "HNCLI_API_URL",
+	"algolia-url": "HNCLI_ALGOLIA_URL",
+	"rss-url":     "HNCLI_RSS_URL",
+	"profile":     "HNCLI_PROFILE",
+}
+
+// notConfigurable are the global flags a config file can't set.
+var notConfigurable = []string{"profile", "help"}
+
+var (
+	profile      string
+	defaultFeed  = "topstories"
+	keyOverrides map[string][]string // from the [keys] table, by action
+)
+
+// keysPrefix starts the config keys of key bindings, e.g. keys.up, which
+// live in the [keys] table.
+const keysPrefix = "keys."
+
+// configPath returns $XDG_CONFIG_HOME/hncli/config, falling back to the
+// platform's user config directory.
+func configPath() (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		var err error
+		if base, err = os.UserConfigDir(); err != nil {
+			return "", fmt.Errorf("locating config directory: %w", err)
+		}
+	}
+	return filepath.Join(base, "hncli", "config"), nil
+}
+
+// readConfig parses the config file at path, returning an empty config if
+// there is none.
+func readConfig(path string) (map[string]any, error) {
+	cfg := make(map[string]any)
+	if _, err := toml.DecodeFile(path, &cfg); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// profiles returns the [profiles] table of cfg, if any.
+func profiles(cfg map[string]any) (map[string]any, error) {
+	switch p := cfg["profiles"].(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return p, nil
+	default:
+		return nil, fmt.Errorf("profiles must be a table of [profiles.<name>] tables")
+	}
+}
+
+// settings returns the settings in cfg, with those of the named profile
+// (if any) replacing the top-level ones.
+func settings(cfg map[string]any, name string) (map[string]any, error) {
+	s := make(map[string]any, len(cfg))
+	for k, v := range cfg {
+		if k != "profiles" {
+			s[k] = v
+		}
+	}
+	if name == "" {
+		return s, nil
+	}
+	ps, err := profiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := ps[name].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("no profile %q in the config file", name)
+	}
+	for k, v := range p {
+		// A profile's [keys] add to the top-level ones.
+		if top, ok := s[k].(map[string]any); ok && k == "keys" {
+			if v, ok := v.(map[string]any); ok {
+				merged := maps.Clone(top)
+				maps.Copy(merged, v)
+				s[k] = merged
+				continue
+			}
+		}
+		s[k] = v
+	}
+	return s, nil
+}
+
+// configFlag returns the global flag of cmd's program for a config key,
+// or nil if the key is a config-only setting. Unknown keys are an error.
+func configFlag(cmd *cobra.Command, key string) (*pflag.Flag, error) {
+	if _, ok := configOnly[key]; ok {
+		return nil, nil
+	}
+	if action, ok := strings.CutPrefix(key, keysPrefix); ok {
+		if !slices.Contains(ui.KeyActions(), action) {
+			return nil, fmt.Errorf("unknown key action %q (want one of %s)", action, strings.Join(ui.KeyActions(), ", "))
+		}
+		return nil, nil
+	}
+	f := cmd.Root().PersistentFlags().Lookup(key)
+	if f == nil || slices.Contains(notConfigurable, key) {
+		return nil, fmt.Errorf("unknown setting %q", key)
+	}
+	return f, nil
+}
+
+// configStrings converts a config value to the strings its flag would be
+// given on the command line, one per element for an array.
+func configStrings(v any) []string {
+	switch v := v.(type) {
+	case []any:
+		var ss []string
+		for _, e := range v {
+			ss = append(ss, configStrings(e)...)
+		}
+		return ss
+	case []string:
+		return v
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// applyConfig fills in the flags not given on the command line from the
+// config file and the selected profile, unless the environment already
+// sets them.
+func applyConfig(cmd *cobra.Command) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	s, err := settings(cfg, flagOrEnv(profile, flagEnv["profile"]))
+	if err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	for key, v := range s {
+		if err := applySetting(cmd, key, v); err != nil {
+			return fmt.Errorf("config %s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// applySetting applies one config setting.
+func applySetting(cmd *cobra.Command, key string, v any) error {
+	if key == "keys" {
+		keys, err := keyTable(cmd, v)
+		if err != nil {
+			return err
+		}
+		keyOverrides = make(map[string][]string, len(keys))
+		for action, v := range keys {
+			keyOverrides[action] = configStrings(v)
+		}
+		return nil
+	}
+	f, err := configFlag(cmd, key)
+	if err != nil {
+		return err
+	}
+	switch key {
+	case "feed":
+		list, err := feedList(fmt.Sprint(v))
+		if err != nil {
+			return err
+		}
+		defaultFeed = list
+		return nil
+	case "open":
+		util.OpenCommand = fmt.Sprint(v)
+		return nil
+	}
+	if cmd.Flags().Changed(key) || os.Getenv(flagEnv[key]) != "" {
+		return nil
+	}
+	// Value.Set rather than Flags().Set, so the flag still counts as unset.
+	for _, s := range configStrings(v) {
+		if err := f.Value.Set(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyTable checks that v, the [keys] table of a config file, binds only
+// known actions, and returns it.
+func keyTable(cmd *cobra.Command, v any) (map[string]any, error) {
+	keys, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("must be a table of action = key or [keys]")
+	}
+	for action, v := range keys {
+		if _, err := configValue(cmd, keysPrefix+action, configStrings(v)); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// feedList returns the Firebase list of the feed named e.g. "top".
+func feedList(name string) (string, error) {
+	var names []string
+	for _, f := range feeds {
+		if strings.EqualFold(f.name, name) {
+			return f.list, nil
+		}
+		names = append(names, strings.ToLower(f.name))
+	}
+	return "", fmt.Errorf("unknown feed %q (want %s)", name, strings.Join(names, ", "))
+}
+
+// configValue converts values given to config set to what the file should
+// hold for key.
+func configValue(cmd *cobra.Command, key string, values []string) (any, error) {
+	f, err := configFlag(cmd, key)
+	if err != nil {
+		return nil, err
+	}
+	typ := "string"
+	switch {
+	case f != nil:
+		typ = f.Value.Type()
+	case strings.HasPrefix(key, keysPrefix):
+		typ = "stringArray"
+	}
+	if !strings.HasSuffix(typ, "Array") && !strings.HasSuffix(typ, "Slice") && len(values) != 1 {
+		return nil, fmt.Errorf("%s takes a single value", key)
+	}
+	switch typ {
+	case "stringArray", "stringSlice":
+		return values, nil
+	case "bool":
+		return strconv.ParseBool(values[0])
+	case "int":
+		return strconv.ParseInt(values[0], 10, 64)
+	case "float64":
+		return strconv.ParseFloat(values[0], 64)
+	case "duration":
+		_, err := time.ParseDuration(values[0])
+		return values[0], err
+	}
+	if key == "feed" {
+		if _, err := feedList(values[0]); err != nil {
+			return nil, err
+		}
+	}
+	return values[0], nil
+}
+
+// writeConfig saves cfg to path.
+func writeConfig(path string, cfg map[string]any) error {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(cfg); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return writeAtomic(path, buf.Bytes())
+}
+
+// configTable returns the table config set changes: the selected
+// profile's, created if need be, or the top level.
+func configTable(cfg map[string]any) (map[string]any, error) {
+	name := flagOrEnv(profile, flagEnv["profile"])
+	if name == "" {
+		return cfg, nil
+	}
+	ps, err := profiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if ps == nil {
+		ps = make(map[string]any)
+		cfg["profiles"] = ps
+	}
+	p, ok := ps[name].(map[string]any)
+	if !ok {
+		p = make(map[string]any)
+		ps[name] = p
+	}
+	return p, nil
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show or change settings in the config file",
+	Long: `Settings are read from $XDG_CONFIG_HOME/hncli/config, a TOML file. Its keys
+are the global flags without the dashes, e.g. count, output, theme, cache-ttl
+or filter, plus:
+
+  feed   the feed hncli opens on without a subcommand (top, new, best, ask,
+         show or jobs)
+  open   the command links are opened with, like HNCLI_OPEN
+
+A flag given on the command line wins over an environment variable, which
+wins over the config file. Named profiles are [profiles.<name>] tables,
+selected with --profile or HNCLI_PROFILE, whose settings replace the
+top-level ones:
+
+  count = 50
+  theme = "light"
+
+  [profiles.work]
+  feed = "best"
+  filter = ["points=100"]
+  source = "rss"
+
+Keys of the TUI are rebound in a [keys] table, by action, on top of the
+keymap picked with the keymap setting (default, vim or emacs). An action
+bound to no keys is turned off; config get keys.<action> shows what an
+action is bound to:
+
+  keymap = "vim"
+
+  [keys]
+  open-url = "O"
+  quit = ["q", "ctrl+q"]
+  refresh = []
+
+With --profile, config get and set work on that profile.`,
+	// The config file is neither applied nor needed to fix it.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the config file's location",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a setting, or its default if the config file leaves it unset",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+		f, err := configFlag(cmd, key)
+		if err != nil {
+			return err
+		}
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		cfg, err := readConfig(path)
+		if err != nil {
+			return err
+		}
+		s, err := settings(cfg, flagOrEnv(profile, flagEnv["profile"]))
+		if err != nil {
+			return err
+		}
+		v, ok := s[key]
+		if action, isKey := strings.CutPrefix(key, keysPrefix); isKey {
+			v, ok = nil, false
+			if keys, isTable := s["keys"].(map[string]any); isTable {
+				v, ok = keys[action]
+			}
+			if !ok {
+				// Bound by the keymap the config file picks.
+				name := ui.DefaultKeyMap
+				if k, isSet := s["keymap"]; isSet {
+					name = fmt.Sprint(k)
+				}
+				preset, err := ui.PresetKeys(name)
+				if err != nil {
+					return err
+				}
+				v, ok = preset[action], true
+			}
+		}
+		switch {
+		case ok:
+		case f != nil:
+			v = f.DefValue
+		default:
+			v = configOnly[key]
+		}
+		for _, s := range configStrings(v) {
+			fmt.Println(s)
+		}
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>...",
+	Short: "Change a setting; give several values for a list such as filter",
+	Long: `Change a setting in the config file. The file is rewritten, so comments in it
+are lost; use config edit to keep them.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		v, err := configValue(cmd, args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		cfg, err := readConfig(path)
+		if err != nil {
+			return err
+		}
+		t, err := configTable(cfg)
+		if err != nil {
+			return err
+		}
+		if action, ok := strings.CutPrefix(args[0], keysPrefix); ok {
+			keys, isTable := t["keys"].(map[string]any)
+			if !isTable {
+				keys = make(map[string]any)
+				t["keys"] = keys
+			}
+			if len(args) == 2 {
+				v = args[1] // a single key reads better unbracketed
+			}
+			keys[action] = v
+		} else {
+			t[args[0]] = v
+		}
+		return writeConfig(path, cfg)
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in $VISUAL or $EDITOR",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		f.Close()
+		editor := flagOrEnv(os.Getenv("VISUAL"), "EDITOR")
+		if editor == "" {
+			editor = "vi"
+		}
+		// The editor may come with arguments, e.g. "code --wait".
+		ed := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+		ed.Stdin, ed.Stdout, ed.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := ed.Run(); err != nil {
+			return fmt.Errorf("running %s: %w", editor, err)
+		}
+		return checkConfig(cmd, path)
+	},
+}
+
+// checkConfig reports the first problem with the config file at path.
+func checkConfig(cmd *cobra.Command, path string) error {
+	cfg, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	ps, err := profiles(cfg)
+	if err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	tables := map[string]any{"": cfg}
+	for name, p := range ps {
+		tables[name] = p
+	}
+	for name, t := range tables {
+		t, ok := t.(map[string]any)
+		if !ok {
+			return fmt.Errorf("config %s: profile %s must be a table", path, name)
+		}
+		for key, v := range t {
+			if key == "profiles" && name == "" {
+				continue
+			}
+			var err error
+			if key == "keys" {
+				_, err = keyTable(cmd, v)
+			} else {
+				_, err = configValue(cmd, key, configStrings(v))
+			}
+			if err != nil {
+				if name != "" {
+					key = "profiles." + name + "." + key
+				}
+				return fmt.Errorf("config %s: %s: %w", path, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	configCmd.AddCommand(configPathCmd, configGetCmd, configSetCmd, configEditCmd)
+}
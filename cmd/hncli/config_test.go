@@ -0,0 +1,249 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// configFlags are what a test root command's flags were set to.
+type configFlags struct {
+	count  int
+	theme  string
+	filter []string
+	apiURL string
+}
+
+// newConfigRoot returns a root command with a few of the global flags,
+// bound to flags, and a subcommand to apply the config file for.
+func newConfigRoot(flags *configFlags) (root, sub *cobra.Command) {
+	root = &cobra.Command{Use: "hncli"}
+	pf := root.PersistentFlags()
+	pf.IntVarP(&flags.count, "count", "n", 30, "")
+	pf.StringVar(&flags.theme, "theme", "hn", "")
+	pf.StringArrayVar(&flags.filter, "filter", nil, "")
+	pf.StringVar(&flags.apiURL, "api-url", "", "")
+	pf.String("profile", "", "")
+	sub = &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(sub)
+	return root, sub
+}
+
+// useConfig points the config file at a temporary directory holding
+// config, and resets what applying a config file changes.
+func useConfig(t *testing.T, config string) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HNCLI_PROFILE", "")
+	t.Setenv("HNCLI_API_URL", "")
+	oldProfile, oldFeed, oldKeys := profile, defaultFeed, keyOverrides
+	t.Cleanup(func() { profile, defaultFeed, keyOverrides = oldProfile, oldFeed, oldKeys })
+	path := filepath.Join(dir, "hncli", "config")
+	if config != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestApplyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		args    []string
+		env     map[string]string
+		profile string
+		want    configFlags
+		feed    string
+		keys    map[string][]string
+		wantErr bool
+	}{
+		{
+			name: "no config file",
+			want: configFlags{count: 30, theme: "hn"},
+		},
+		{
+			name:   "config sets flags",
+			config: "count = 50\ntheme = \"light\"\nfilter = [\"points=100\", \"comments=5\"]\n",
+			want:   configFlags{count: 50, theme: "light", filter: []string{"points=100", "comments=5"}},
+		},
+		{
+			name:   "flag wins over config",
+			config: "count = 50\ntheme = \"light\"\n",
+			args:   []string{"--count", "10"},
+			want:   configFlags{count: 10, theme: "light"},
+		},
+		{
+			name:   "env wins over config",
+			config: "api-url = \"http://config.example\"\n",
+			env:    map[string]string{"HNCLI_API_URL": "http://env.example"},
+			want:   configFlags{count: 30, theme: "hn"},
+		},
+		{
+			name:    "profile replaces top-level settings",
+			config:  "count = 50\ntheme = \"light\"\n[profiles.work]\ncount = 70\nfeed = \"best\"\n",
+			profile: "work",
+			want:    configFlags{count: 70, theme: "light"},
+			feed:    "beststories",
+		},
+		{
+			name:   "profile from env",
+			config: "count = 50\n[profiles.work]\ncount = 70\n",
+			env:    map[string]string{"HNCLI_PROFILE": "work"},
+			want:   configFlags{count: 70, theme: "hn"},
+		},
+		{
+			name:    "profile keys add to top-level keys",
+			config:  "[keys]\nup = \"w\"\nquit = [\"q\", \"ctrl+q\"]\n[profiles.work.keys]\nup = [\"i\"]\ndown = \"s\"\n",
+			profile: "work",
+			want:    configFlags{count: 30, theme: "hn"},
+			keys:    map[string][]string{"up": {"i"}, "down": {"s"}, "quit": {"q", "ctrl+q"}},
+		},
+		{
+			name:    "unknown profile",
+			config:  "count = 50\n",
+			profile: "home",
+			wantErr: true,
+		},
+		{
+			name:    "unknown setting",
+			config:  "colour = \"red\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad value",
+			config:  "count = \"many\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown feed",
+			config:  "feed = \"worst\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown key action",
+			config:  "[keys]\njump = \"x\"\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfig(t, tt.config)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			profile, defaultFeed, keyOverrides = tt.profile, "topstories", nil
+			var got configFlags
+			_, sub := newConfigRoot(&got)
+			if err := sub.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			err := applyConfig(sub)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("applyConfig succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flags = %+v, want %+v", got, tt.want)
+			}
+			feed := tt.feed
+			if feed == "" {
+				feed = "topstories"
+			}
+			if defaultFeed != feed {
+				t.Errorf("default feed = %q, want %q", defaultFeed, feed)
+			}
+			if !reflect.DeepEqual(keyOverrides, tt.keys) {
+				t.Errorf("key overrides = %v, want %v", keyOverrides, tt.keys)
+			}
+		})
+	}
+}
+
+func TestConfigSet(t *testing.T) {
+	path := useConfig(t, "# set by hand\ncount = 40\n")
+	var flags configFlags
+	_, sub := newConfigRoot(&flags)
+	set := func(args ...string) error { return configSetCmd.RunE(sub, args) }
+
+	for _, args := range [][]string{
+		{"count", "50"},
+		{"filter", "points=100", "comments=5"},
+		{"feed", "ask"},
+		{"keys.open-url", "O"},
+		{"keys.quit", "q", "ctrl+q"},
+	} {
+		if err := set(args...); err != nil {
+			t.Fatalf("config set %v: %v", args, err)
+		}
+	}
+	profile = "work"
+	if err := set("theme", "mono"); err != nil {
+		t.Fatal(err)
+	}
+	profile = ""
+
+	for _, args := range [][]string{
+		{"count", "lots"},
+		{"theme", "light", "mono"},
+		{"feed", "worst"},
+		{"colour", "red"},
+		{"profile", "work"},
+		{"keys.jump", "x"},
+	} {
+		if err := set(args...); err == nil {
+			t.Errorf("config set %v succeeded, want an error", args)
+		}
+	}
+
+	cfg, err := readConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"count":  int64(50),
+		"filter": []any{"points=100", "comments=5"},
+		"feed":   "ask",
+		"keys":   map[string]any{"open-url": "O", "quit": []any{"q", "ctrl+q"}},
+		"profiles": map[string]any{
+			"work": map[string]any{"theme": "mono"},
+		},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("config after set = %#v, want %#v", cfg, want)
+	}
+	if err := checkConfig(sub, path); err != nil {
+		t.Errorf("checkConfig on what config set wrote: %v", err)
+	}
+
+	// What was written applies as it was set.
+	var got configFlags
+	_, sub = newConfigRoot(&got)
+	profile = "work"
+	if err := applyConfig(sub); err != nil {
+		t.Fatal(err)
+	}
+	if got.count != 50 || got.theme != "mono" || !slices.Equal(got.filter, []string{"points=100", "comments=5"}) {
+		t.Errorf("flags from the written config = %+v, want count 50, theme mono and both filters", got)
+	}
+	if defaultFeed != "askstories" {
+		t.Errorf("default feed = %q, want askstories", defaultFeed)
+	}
+	if !slices.Equal(keyOverrides["quit"], []string{"q", "ctrl+q"}) {
+		t.Errorf("quit keys = %v, want [q ctrl+q]", keyOverrides["quit"])
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/util"
+)
+
+// listData is what story list and search templates are evaluated against:
+// the item's fields plus its 1-based position in the list.
+type listData struct {
+	*api.Item
+	Index int
+}
+
+// threadData is what item templates are evaluated against: the story's
+// fields plus its comment tree, and a poll's options. Each comment exposes
+// the item fields plus .Depth and .Replies.
+type threadData struct {
+	*api.Item
+	Comments []*api.Comment
+	Options  []*api.Item
+}
+
+// userData is what user templates are evaluated against: the profile's
+// fields plus the submissions that were fetched.
+type userData struct {
+	*api.User
+	Submissions []*api.Item
+}
+
+// templateFuncs are the helpers available to --format templates.
+var templateFuncs = template.FuncMap{
+	"hostname":  util.Hostname,
+	"age":       api.Age,
+	"stripHTML": util.StripHTML,
+	"hnURL":     hnURL,
+	"truncate":  func(n int, s string) string { return util.Truncate(s, n) }, // n first, for pipelines
+	"repeat":    strings.Repeat,
+	"indent":    func(depth int) string { return strings.Repeat("  ", depth) },
+	"lines":     func(s string) []string { return strings.Split(s, "\n") },
+	"oneline":   func(s string) string { return strings.Join(strings.Fields(s), " ") },
+	"clock":     func(unix int64) string { return time.Unix(unix, 0).Format(time.TimeOnly) },
+	"votes":     api.Votes,
+	"bar":       util.Bar,
+	"percent":   util.Percent,
+}
+
+// hnURL returns the news.ycombinator.com URL for an item ID or a username.
+func hnURL(v any) (string, error) {
+	switch v := v.(type) {
+	case int:
+		return fmt.Sprintf("https://news.ycombinator.com/item?id=%d", v), nil
+	case string:
+		return fmt.Sprintf("https://news.ycombinator.com/user?id=%s", v), nil
+	}
+	return "", fmt.Errorf("hnURL: want item ID or username, got %T", v)
+}
+
+// formatTemplate parses the --format template, or def if none was given.
+// "@path" reads the template from a file; inline templates may use \t and
+// \n escapes.
+func formatTemplate(def string) (*template.Template, error) {
+	text := def
+	switch {
+	case strings.HasPrefix(format, "@"):
+		b, err := os.ReadFile(format[1:])
+		if err != nil {
+			return nil, fmt.Errorf("reading format: %w", err)
+		}
+		text = string(b)
+	case format != "":
+		text = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+	}
+	t, err := template.New("format").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %w", err)
+	}
+	return t, nil
+}
+
+// execute writes t evaluated against data to stdout, adding a trailing
+// newline if the template did not produce one.
+func execute(t *template.Template, data any) error {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return err
+	}
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err := os.Stdout.Write(buf.Bytes())
+	return err
+}
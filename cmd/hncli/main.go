@@ -1,33 +1,189 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"maps"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/spf13/cobra"
-	"golang.org/x/term"
 	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/rss"
 	"github.com/hexadecimoose/hncli/internal/ui"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var version = "dev" // set by -ldflags at build time
 
 var (
-	count  int
-	plain  bool
-	client *api.Client
+	count      int
+	page       int
+	offset     int
+	plain      bool
+	output     string
+	format     string
+	strategy   string
+	noCache    bool
+	cacheTTL   time.Duration
+	offline    bool
+	verbose    bool
+	retries    int
+	rateRPS    float64
+	apiURL     string
+	algoliaURL string
+	refresh    time.Duration
+	theme      string
+	keymap     string
+	stream     bool
+	client     *api.Client
 )
 
-// isPlain returns true if plain mode is active (flag set, or stdout is not a TTY).
+// minRefresh is the shortest --auto-refresh interval, to stay polite to
+// the API.
+const minRefresh = 5 * time.Second
+
+// uiOptions builds the TUI configuration from flags.
+func uiOptions() []ui.Option {
+	return []ui.Option{ui.WithAutoRefresh(refresh), ui.WithStreaming(stream)}
+}
+
+// themeNames returns the names of the built-in themes, sorted.
+func themeNames() []string {
+	return slices.Sorted(maps.Keys(ui.Themes))
+}
+
+// checkTheme validates --theme and restyles the TUI with it.
+func checkTheme() error {
+	t, ok := ui.Themes[theme]
+	if !ok {
+		return fmt.Errorf("unknown --theme %q (want %s)", theme, strings.Join(themeNames(), ", "))
+	}
+	ui.SetTheme(t)
+	return nil
+}
+
+// checkKeys validates --keymap and the [keys] table of the config file,
+// and rebinds the TUI's keys with them.
+func checkKeys() error {
+	km, err := ui.NewKeyMap(keymap, keyOverrides)
+	if err != nil {
+		return err
+	}
+	ui.SetKeyMap(km)
+	return nil
+}
+
+// checkRefresh validates --auto-refresh.
+func checkRefresh() error {
+	switch {
+	case refresh < 0, refresh > 0 && refresh < minRefresh:
+		return fmt.Errorf("--auto-refresh must be 0 or at least %s", minRefresh)
+	case refresh > 0 && offline:
+		return fmt.Errorf("--auto-refresh needs the network and cannot be used with --offline")
+	}
+	return nil
+}
+
+// clientOptions builds the API client configuration from flags and the
+// environment. Flags take precedence over environment variables.
+func clientOptions() ([]api.Option, error) {
+	s, err := api.ParseStrategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+	opts := []api.Option{
+		api.WithStrategy(s),
+		api.WithUserAgent("hncli/" + version),
+		api.WithOffline(offline),
+		api.WithRetry(api.RetryPolicy{
+			MaxRetries: retries,
+			BaseDelay:  api.DefaultRetryPolicy.BaseDelay,
+			MaxDelay:   api.DefaultRetryPolicy.MaxDelay,
+		}),
+		api.WithRateLimit(rateRPS, max(1, int(rateRPS))),
+	}
+	if u := flagOrEnv(apiURL, "HNCLI_API_URL"); u != "" {
+		opts = append(opts, api.WithBaseURL(u))
+	}
+	if u := flagOrEnv(algoliaURL, "HNCLI_ALGOLIA_URL"); u != "" {
+		opts = append(opts, api.WithAlgoliaURL(u))
+	}
+
+	if offline && noCache {
+		return nil, fmt.Errorf("--offline reads from the cache and cannot be combined with --no-cache")
+	}
+	if !noCache {
+		cache, err := openCache()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, api.WithCache(cache))
+	}
+	if verbose {
+		l, err := openLog()
+		if err != nil {
+			return nil, fmt.Errorf("opening verbose log: %w", err)
+		}
+		logf = l.Printf
+		opts = append(opts, api.WithLogf(logf))
+	}
+	return opts, nil
+}
+
+// flagOrEnv returns the flag value if set, otherwise the environment variable.
+func flagOrEnv(flag, env string) string {
+	if flag != "" {
+		return flag
+	}
+	return os.Getenv(env)
+}
+
+// logf writes --verbose diagnostics. It is a no-op unless --verbose is set.
+var logf = func(format string, args ...any) {}
+
+// openLog returns the destination for --verbose output: stderr in plain
+// mode, or a file in the cache directory while the TUI owns the terminal.
+func openLog() (*log.Logger, error) {
+	if isPlain() {
+		return log.New(os.Stderr, "hncli: ", log.Ltime|log.Lmicroseconds), nil
+	}
+	dir, err := api.DefaultCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "verbose.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return log.New(f, "", log.LstdFlags|log.Lmicroseconds), nil
+}
+
+// isPlain returns true if plain mode is active (flag set, structured or
+// templated output requested, or stdout is not a TTY).
 func isPlain() bool {
-	return plain || !term.IsTerminal(int(os.Stdout.Fd()))
+	return plain || structured() || format != "" || !term.IsTerminal(int(os.Stdout.Fd()))
 }
 
 func main() {
-	client = api.New()
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl-C cancels in-flight requests instead of waiting for them to time out.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := rootCmd.ExecuteContext(ctx)
+	if verbose && client != nil {
+		st := client.Stats()
+		logf("%d requests, %d retries, %d failures", st.Requests, st.Retries, st.Failures)
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -41,44 +197,168 @@ var rootCmd = &cobra.Command{
 
 Run without arguments to launch the interactive TUI browser.
 Use subcommands for quick access to specific feeds.
-Use --plain / -p (or pipe output) for plain text output.`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		if isPlain() {
-			items, err := client.TopStories(count)
-			if err != nil {
-				return err
-			}
-			printStories(items)
-			return nil
+Use --plain / -p (or pipe output) for plain text output.
+Use --output json|ndjson for machine-readable output.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfig(cmd); err != nil {
+			return err
+		}
+		if err := checkTheme(); err != nil {
+			return err
+		}
+		if err := checkKeys(); err != nil {
+			return err
+		}
+		if err := checkOutput(); err != nil {
+			return err
+		}
+		opts, err := clientOptions()
+		if err != nil {
+			return err
+		}
+		client = api.New(opts...)
+		if err := checkRefresh(); err != nil {
+			return err
 		}
-		return ui.RunWithLoader(client, "Hacker News · Top Stories", func() ([]*api.Item, error) {
-			return client.TopStories(count)
-		})
+		return checkSource()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStories(cmd.Context(), defaultFeed)
 	},
 }
 
 func init() {
 	rootCmd.PersistentFlags().IntVarP(&count, "count", "n", 30, "number of stories to fetch")
 	rootCmd.PersistentFlags().BoolVarP(&plain, "plain", "p", false, "plain text output (no TUI); auto-enabled when stdout is not a TTY")
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", outputText, "output format: text, json or ndjson (json/ndjson imply --plain)")
+	rootCmd.PersistentFlags().StringVar(&format, "format", "", "text/template for plain output, or @file to read it from a file (implies --plain)")
+	rootCmd.PersistentFlags().StringVar(&strategy, "strategy", string(api.StrategyFirebase), "comment thread source: firebase (fresh) or algolia (fast); falls back to the other on failure")
+
+	rootCmd.PersistentFlags().StringVar(&theme, "theme", ui.DefaultTheme, "TUI colour scheme: "+strings.Join(themeNames(), ", "))
+	rootCmd.PersistentFlags().StringVar(&keymap, "keymap", ui.DefaultKeyMap, "TUI key bindings: "+strings.Join(ui.KeyMapNames(), ", ")+"; rebind single actions under [keys] in the config file")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "use this profile from the config file (env HNCLI_PROFILE; see hncli config)")
+
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "bypass the on-disk response cache")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", 0, "treat cached responses older than this as stale (default: per endpoint)")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "log retries and request counts to stderr (to verbose.log in the cache directory while the TUI is running)")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", api.DefaultRetryPolicy.MaxRetries, "retries for network errors, 5xx and 429 responses")
+	rootCmd.PersistentFlags().Float64Var(&rateRPS, "rate-limit", api.DefaultRateLimit, "maximum API requests per second across all fetches (0 for unlimited)")
+	rootCmd.PersistentFlags().DurationVar(&refresh, "auto-refresh", 0, "in the TUI, check this often (e.g. 30s) for score, comment count and new comment changes on screen; 0 turns it off")
+	rootCmd.PersistentFlags().BoolVar(&stream, "stream", true, "follow changes over the API's event streams (with --auto-refresh and in tail) instead of polling")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "never touch the network; show whatever was last cached (see hncli sync)")
+	rootCmd.PersistentFlags().StringVar(&apiURL, "api-url", "", "HN Firebase API base URL, e.g. a mirror (env HNCLI_API_URL; default "+api.DefaultBaseURL+")")
+	rootCmd.PersistentFlags().StringVar(&algoliaURL, "algolia-url", "", "Algolia HN search API base URL (env HNCLI_ALGOLIA_URL; default "+api.DefaultAlgoliaURL+")")
+
+	rootCmd.PersistentFlags().StringVar(&source, "source", sourceFirebase, "where story feeds come from: firebase or rss (hnrss.org)")
+	rootCmd.PersistentFlags().StringArrayVar(&rssFilters, "filter", nil, "hnrss filter as key=value, e.g. points=100 (repeatable; with --source rss)")
+	rootCmd.PersistentFlags().StringArrayVar(&searchTabs, "tab", nil, "extra TUI tab for a saved search, as name=query (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&rssURL, "rss-url", "", "hnrss base URL (env HNCLI_RSS_URL; default "+rss.DefaultBaseURL+")")
+
+	for _, cmd := range []*cobra.Command{rootCmd, topCmd, newCmd, bestCmd, askCmd, showCmd, jobsCmd} {
+		cmd.Flags().IntVar(&page, "page", 1, "page of stories to print, -n per page (plain output)")
+		cmd.Flags().IntVar(&offset, "offset", 0, "skip this many stories (plain output)")
+	}
+
+	rootCmd.AddCommand(topCmd, newCmd, bestCmd, askCmd, showCmd, jobsCmd, itemCmd, userCmd, searchCmd, tailCmd, watchCmd, rssCmd, cacheCmd, syncCmd, configCmd)
+}
+
+// feed is a story list behind one of the feed commands.
+type feed struct {
+	name  string // tab label
+	list  string // Firebase list name
+	title string
+}
+
+// feeds are the story lists behind the feed commands, in TUI tab order.
+var feeds = []feed{
+	{"Top", "topstories", "Hacker News · Top Stories"},
+	{"New", "newstories", "Hacker News · New Stories"},
+	{"Best", "beststories", "Hacker News · Best Stories"},
+	{"Ask", "askstories", "Hacker News · Ask HN"},
+	{"Show", "showstories", "Hacker News · Show HN"},
+	{"Jobs", "jobstories", "Hacker News · Jobs"},
+}
+
+// storyLoader returns a loader for the named Firebase list, or its hnrss
+// equivalent with --source rss. Firebase loaders fetch count stories and
+// the rest of the list's IDs, so the TUI can page through the whole list.
+func storyLoader(list string) (ui.Loader, error) {
+	if source == sourceRSS {
+		filters, err := parseFilters(rssFilters)
+		if err != nil {
+			return nil, err
+		}
+		return rssLoader(rssFeeds[list], filters), nil
+	}
+	return func(ctx context.Context) ([]*api.Item, []int, error) {
+		ids, err := client.StoryIDs(ctx, list)
+		if err != nil {
+			return nil, nil, err
+		}
+		items, err := client.ItemsContext(ctx, ids[:min(count, len(ids))])
+		return items, ids, err
+	}, nil
+}
+
+// storyPage fetches count stories from the named Firebase list (or its
+// hnrss equivalent with --source rss), skipping the first offset.
+func storyPage(ctx context.Context, list string, offset int) ([]*api.Item, error) {
+	if source == sourceRSS {
+		filters, err := parseFilters(rssFilters)
+		if err != nil {
+			return nil, err
+		}
+		// hnrss has no offset parameter: fetch the stories before the page too.
+		entries, err := rssClient.FeedContext(ctx, rssFeeds[list], offset+count, filters)
+		if err != nil {
+			return nil, err
+		}
+		items := rss.HNItems(entries)
+		return items[min(offset, len(items)):], nil
+	}
+	return client.StoriesPageContext(ctx, list, offset, count)
+}
 
-	rootCmd.AddCommand(topCmd, newCmd, bestCmd, askCmd, showCmd, jobsCmd, itemCmd, userCmd, searchCmd)
+// runStories prints count stories from the named Firebase list (or its
+// hnrss equivalent with --source rss), or opens the TUI on that list's tab.
+func runStories(ctx context.Context, list string) error {
+	if isPlain() {
+		offset, err := storyOffset()
+		if err != nil {
+			return err
+		}
+		items, err := storyPage(ctx, list, offset)
+		if err != nil {
+			return err
+		}
+		return emitStories(items)
+	}
+	tabs, err := storyTabs()
+	if err != nil {
+		return err
+	}
+	active := slices.IndexFunc(feeds, func(f feed) bool { return f.list == list })
+	return ui.RunTabs(ctx, client, tabs, active, uiOptions()...)
+}
+
+// storyOffset returns how many stories --page or --offset skip.
+func storyOffset() (int, error) {
+	switch {
+	case page < 1:
+		return 0, fmt.Errorf("--page must be at least 1")
+	case offset < 0:
+		return 0, fmt.Errorf("--offset must not be negative")
+	case page > 1 && offset > 0:
+		return 0, fmt.Errorf("--page and --offset cannot be used together")
+	}
+	return offset + (page-1)*count, nil
 }
 
 var topCmd = &cobra.Command{
 	Use:   "top",
 	Short: "Top stories",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if isPlain() {
-			items, err := client.TopStories(count)
-			if err != nil {
-				return err
-			}
-			printStories(items)
-			return nil
-		}
-		return ui.RunWithLoader(client, "Hacker News · Top Stories", func() ([]*api.Item, error) {
-			return client.TopStories(count)
-		})
+		return runStories(cmd.Context(), "topstories")
 	},
 }
 
@@ -86,17 +366,7 @@ var newCmd = &cobra.Command{
 	Use:   "new",
 	Short: "Newest stories",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if isPlain() {
-			items, err := client.NewStories(count)
-			if err != nil {
-				return err
-			}
-			printStories(items)
-			return nil
-		}
-		return ui.RunWithLoader(client, "Hacker News · New Stories", func() ([]*api.Item, error) {
-			return client.NewStories(count)
-		})
+		return runStories(cmd.Context(), "newstories")
 	},
 }
 
@@ -104,17 +374,7 @@ var bestCmd = &cobra.Command{
 	Use:   "best",
 	Short: "Best stories",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if isPlain() {
-			items, err := client.BestStories(count)
-			if err != nil {
-				return err
-			}
-			printStories(items)
-			return nil
-		}
-		return ui.RunWithLoader(client, "Hacker News · Best Stories", func() ([]*api.Item, error) {
-			return client.BestStories(count)
-		})
+		return runStories(cmd.Context(), "beststories")
 	},
 }
 
@@ -122,17 +382,7 @@ var askCmd = &cobra.Command{
 	Use:   "ask",
 	Short: "Ask HN stories",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if isPlain() {
-			items, err := client.AskStories(count)
-			if err != nil {
-				return err
-			}
-			printStories(items)
-			return nil
-		}
-		return ui.RunWithLoader(client, "Hacker News · Ask HN", func() ([]*api.Item, error) {
-			return client.AskStories(count)
-		})
+		return runStories(cmd.Context(), "askstories")
 	},
 }
 
@@ -140,17 +390,7 @@ var showCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show HN stories",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if isPlain() {
-			items, err := client.ShowStories(count)
-			if err != nil {
-				return err
-			}
-			printStories(items)
-			return nil
-		}
-		return ui.RunWithLoader(client, "Hacker News · Show HN", func() ([]*api.Item, error) {
-			return client.ShowStories(count)
-		})
+		return runStories(cmd.Context(), "showstories")
 	},
 }
 
@@ -158,17 +398,7 @@ var jobsCmd = &cobra.Command{
 	Use:   "jobs",
 	Short: "Job postings",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if isPlain() {
-			items, err := client.JobStories(count)
-			if err != nil {
-				return err
-			}
-			printStories(items)
-			return nil
-		}
-		return ui.RunWithLoader(client, "Hacker News · Jobs", func() ([]*api.Item, error) {
-			return client.JobStories(count)
-		})
+		return runStories(cmd.Context(), "jobstories")
 	},
 }
 
@@ -182,42 +412,12 @@ var itemCmd = &cobra.Command{
 			return fmt.Errorf("invalid item ID: %q", args[0])
 		}
 		if isPlain() {
-			return printItem(client, id)
-		}
-		return ui.RunItem(client, id)
-	},
-}
-
-var userCmd = &cobra.Command{
-	Use:   "user <username>",
-	Short: "View a user's profile and recent submissions",
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		if isPlain() {
-			return printUser(client, args[0])
-		}
-		return ui.RunUser(client, args[0])
-	},
-}
-
-var searchCmd = &cobra.Command{
-	Use:   "search <query>",
-	Short: "Search Hacker News via Algolia",
-	Args:  cobra.MinimumNArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		q := strings.Join(args, " ")
-		items, err := client.Search(q, count)
-		if err != nil {
-			return fmt.Errorf("search failed: %w", err)
-		}
-		if isPlain() {
-			for i, item := range items {
-				fmt.Printf("%d. %s\n   %s\n   https://news.ycombinator.com/item?id=%d\n\n",
-					i+1, item.Title, item.URL, item.ID)
+			thread, err := client.ThreadContext(cmd.Context(), id)
+			if err != nil {
+				return err
 			}
-			return nil
+			return emitThread(thread)
 		}
-		return ui.RunWithItems(client, fmt.Sprintf("Search: %q", q), items)
+		return ui.RunItem(cmd.Context(), client, id, uiOptions()...)
 	},
 }
-
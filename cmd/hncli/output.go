@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+)
+
+// Output formats accepted by --output.
+const (
+	outputText   = "text"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+)
+
+// checkOutput validates the --output and --format flags.
+func checkOutput() error {
+	switch output {
+	case outputText, outputJSON, outputNDJSON:
+	default:
+		return fmt.Errorf("unknown output format %q (want text, json or ndjson)", output)
+	}
+	if format != "" && structured() {
+		return fmt.Errorf("--format cannot be combined with --output %s", output)
+	}
+	return nil
+}
+
+// structured reports whether machine-readable output was requested.
+func structured() bool { return output == outputJSON || output == outputNDJSON }
+
+// ndComment is a comment as written to an NDJSON stream: one line per
+// comment, with depth standing in for the nesting of the JSON form.
+type ndComment struct {
+	*api.Item
+	Depth int `json:"depth"`
+}
+
+// userOutput is the JSON form of a user profile and its submissions.
+type userOutput struct {
+	User        *api.User   `json:"user"`
+	Submissions []*api.Item `json:"submissions"`
+}
+
+// staleNotice reports how old offline data is. It heads the default plain
+// text output and goes to stderr otherwise, so structured and templated
+// output stay machine-readable.
+func staleNotice() {
+	if !client.Offline() {
+		return
+	}
+	t := client.StaleSince()
+	if t.IsZero() {
+		return
+	}
+	msg := fmt.Sprintf("(offline · stale since %s, %s)", t.Local().Format("Jan 2 15:04"), api.Age(t.Unix()))
+	if structured() || format != "" {
+		fmt.Fprintln(os.Stderr, "hncli: "+msg)
+		return
+	}
+	fmt.Printf("%s\n\n", msg)
+}
+
+// writeJSON writes v to stdout as indented JSON.
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeNDJSON writes each value to stdout as a single line of JSON.
+func writeNDJSON(vs ...any) error {
+	enc := json.NewEncoder(os.Stdout)
+	for _, v := range vs {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitStories writes a story list in the selected output format.
+func emitStories(items []*api.Item) error {
+	staleNotice()
+	switch output {
+	case outputJSON:
+		return writeJSON(nonNil(items))
+	case outputNDJSON:
+		for _, item := range items {
+			if err := writeNDJSON(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return printStories(items)
+}
+
+// emitSearchResults writes search hits in the selected output format.
+// Structured output is as for a story list.
+func emitSearchResults(items []*api.Item) error {
+	if structured() {
+		return emitStories(items)
+	}
+	staleNotice()
+	return printSearchResults(items)
+}
+
+// emitThread writes a story and its comment tree in the selected output
+// format. NDJSON output has the story on the first line followed by every
+// comment in depth-first order.
+func emitThread(t *api.Thread) error {
+	staleNotice()
+	switch output {
+	case outputJSON:
+		if t.Comments == nil {
+			t.Comments = []*api.Comment{}
+		}
+		return writeJSON(t)
+	case outputNDJSON:
+		if err := writeNDJSON(t.Story); err != nil {
+			return err
+		}
+		for _, o := range t.Options {
+			if err := writeNDJSON(o); err != nil {
+				return err
+			}
+		}
+		return writeCommentLines(t.Comments)
+	}
+	return printThread(t)
+}
+
+// writeCommentLines writes a comment tree depth-first as NDJSON.
+func writeCommentLines(comments []*api.Comment) error {
+	for _, c := range comments {
+		if err := writeNDJSON(ndComment{Item: c.Item, Depth: c.Depth}); err != nil {
+			return err
+		}
+		if err := writeCommentLines(c.Replies); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitUser writes a user profile and their recent submissions in the
+// selected output format. NDJSON output has the user on the first line
+// followed by one submission per line.
+func emitUser(user *api.User, items []*api.Item) error {
+	staleNotice()
+	switch output {
+	case outputJSON:
+		return writeJSON(userOutput{User: user, Submissions: nonNil(items)})
+	case outputNDJSON:
+		if err := writeNDJSON(user); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := writeNDJSON(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return printUser(user, items)
+}
+
+// nonNil returns items, or an empty slice if it is nil, so that empty
+// results encode as [] rather than null.
+func nonNil(items []*api.Item) []*api.Item {
+	if items == nil {
+		return []*api.Item{}
+	}
+	return items
+}
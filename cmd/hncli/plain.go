@@ -1,99 +1,123 @@
 package main
 
-import (
-	"fmt"
-	"strings"
+import "github.com/hexadecimoose/hncli/internal/api"
 
-	"github.com/hexadecimoose/hncli/internal/api"
-	"github.com/hexadecimoose/hncli/internal/util"
+// Default plain text templates. --format replaces the one for the command
+// being run; see format.go for the data each template is evaluated against.
+const (
+	// storyTemplate renders one entry of a story list.
+	storyTemplate = `{{.Index}}. {{if eq .Type "poll"}}[poll] {{end}}{{.Title}} ({{.Score}} pts)
+{{if .URL}}   {{.URL}}
+{{end}}   {{.Descendants}} comments · by {{.By}} · {{.Age}} · {{hnURL .ID}}
+
+`
+
+	// searchTemplate renders one search hit. Comment hits show the story
+	// they were posted on and the start of the comment.
+	searchTemplate = `{{.Index}}. {{if eq .Type "comment"}}{{.By}} on: {{.StoryTitle}}
+   {{truncate 200 (oneline (stripHTML .Text))}}
+{{else}}{{.Title}}
+   {{.URL}}
+{{end}}   {{hnURL .ID}}
+
+`
+
+	// tailTemplate renders one item printed by tail.
+	tailTemplate = `{{clock .Time}} {{if eq .Type "comment"}}{{.By}} commented: {{truncate 200 (oneline (stripHTML .Text))}}
+{{else}}{{.By}} posted {{.Type}}: {{.Title}}{{if .URL}} ({{hostname .URL}}){{end}}
+{{end}}         {{hnURL .ID}}
+`
+
+	// watchTemplate renders one alert raised by watch: the rule matched,
+	// then the item as tail prints it.
+	watchTemplate = `[{{.Rule}}] ` + tailTemplate
+
+	// threadTemplate renders a story, a poll's options as a bar chart, and
+	// the full comment thread, indenting each reply two spaces deeper than
+	// its parent.
+	threadTemplate = `{{.Title}}
+{{repeat "─" (len .Title)}}
+{{if .URL}}URL:      {{.URL}}
+{{end}}Score:    {{.Score}}
+Author:   {{.By}}
+Posted:   {{.Age}}
+Comments: {{.Descendants}}
+HN:       {{hnURL .ID}}
+{{if .Text}}
+{{stripHTML .Text}}
+{{end}}{{if .Options}}{{$total := votes .Options}}
+{{range .Options}}{{stripHTML .Text}}
+  {{bar .Score $total 30}} {{.Score}} votes · {{percent .Score $total}}%
+{{end}}{{$total}} votes in total
+{{end}}{{if .Comments}}
+{{repeat "─" 60}}
+
+{{range .Comments}}{{template "comment" .}}{{end}}{{end}}
+{{- define "comment"}}{{if .Visible}}{{$indent := indent .Depth}}
+{{- if .Deleted}}{{$indent}}[deleted]
+
+{{else if .Dead}}{{$indent}}[dead]
+
+{{else}}{{$indent}}{{.By}}  ({{.Age}})
+{{range lines (stripHTML .Text)}}{{if .}}{{$indent}}{{.}}{{end}}
+{{end}}
+{{end}}{{range .Replies}}{{template "comment" .}}{{end}}{{end}}{{end}}`
+
+	// userTemplate renders a user profile and their recent submissions.
+	userTemplate = `User:   {{.ID}}
+Karma:  {{.Karma}}
+{{if .About}}About:  {{stripHTML .About}}
+{{end}}HN:     {{hnURL .ID}}
+
+Recent submissions:
+
+{{range .Submissions}}{{if eq .Type "comment"}}  on: {{.StoryTitle}} ({{.Age}})
+  {{truncate 200 (oneline (stripHTML .Text))}}
+  {{hnURL .ID}}
+{{else}}  {{.Title}} ({{.Score}} pts · {{.Descendants}} comments · {{.Age}})
+{{if .URL}}  {{.URL}}
+{{end}}{{end}}
+{{end}}`
 )
 
 // printStories prints a story list to stdout in plain text.
-func printStories(items []*api.Item) {
-	for i, item := range items {
-		fmt.Printf("%d. %s (%d pts)\n", i+1, item.Title, item.Score)
-		if item.URL != "" {
-			fmt.Printf("   %s\n", item.URL)
-		}
-		fmt.Printf("   %d comments · by %s · %s · https://news.ycombinator.com/item?id=%d\n\n",
-			item.Descendants, item.By, item.Age(), item.ID)
-	}
+func printStories(items []*api.Item) error {
+	return printList(storyTemplate, items)
+}
+
+// printSearchResults prints search hits to stdout in plain text.
+func printSearchResults(items []*api.Item) error {
+	return printList(searchTemplate, items)
 }
 
-// printItem prints a story and its comments to stdout in plain text.
-func printItem(client *api.Client, id int) error {
-	story, err := client.Item(id)
+// printList executes the --format template, or def, once per item.
+func printList(def string, items []*api.Item) error {
+	t, err := formatTemplate(def)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%s\n", story.Title)
-	fmt.Printf("%s\n", strings.Repeat("─", len(story.Title)))
-	if story.URL != "" {
-		fmt.Printf("URL:      %s\n", story.URL)
-	}
-	fmt.Printf("Score:    %d\n", story.Score)
-	fmt.Printf("Author:   %s\n", story.By)
-	fmt.Printf("Posted:   %s\n", story.Age())
-	fmt.Printf("Comments: %d\n", story.Descendants)
-	fmt.Printf("HN:       https://news.ycombinator.com/item?id=%d\n", story.ID)
-	if story.Text != "" {
-		fmt.Printf("\n%s\n", util.StripHTML(story.Text))
-	}
-
-	if len(story.Kids) == 0 {
-		return nil
-	}
-
-	limit := len(story.Kids)
-	if limit > 50 {
-		limit = 50
-	}
-	fmt.Printf("\n%s\n\n", strings.Repeat("─", 60))
-
-	for _, kid := range story.Kids[:limit] {
-		c, err := client.Item(kid)
-		if err != nil || c == nil || c.Deleted || c.Dead {
-			continue
+	for i, item := range items {
+		if err := execute(t, listData{Index: i + 1, Item: item}); err != nil {
+			return err
 		}
-		fmt.Printf("%s  (%s)\n", c.By, c.Age())
-		fmt.Printf("%s\n\n", util.StripHTML(c.Text))
 	}
 	return nil
 }
 
-// printUser prints a user profile to stdout in plain text.
-func printUser(client *api.Client, username string) error {
-	user, err := client.User(username)
+// printThread prints a story and its full comment thread to stdout in plain text.
+func printThread(thread *api.Thread) error {
+	t, err := formatTemplate(threadTemplate)
 	if err != nil {
 		return err
 	}
-	if user == nil {
-		return fmt.Errorf("user %q not found", username)
-	}
-	fmt.Printf("User:   %s\n", user.ID)
-	fmt.Printf("Karma:  %d\n", user.Karma)
-	if user.About != "" {
-		fmt.Printf("About:  %s\n", util.StripHTML(user.About))
-	}
-	fmt.Printf("HN:     https://news.ycombinator.com/user?id=%s\n", user.ID)
-
-	// Print up to 10 recent story submissions.
-	count := 0
-	fmt.Printf("\nRecent submissions:\n\n")
-	for _, id := range user.Submitted {
-		if count >= 10 {
-			break
-		}
-		item, e := client.Item(id)
-		if e != nil || item == nil || item.Type != "story" || item.Dead || item.Deleted {
-			continue
-		}
-		fmt.Printf("  %s (%d pts · %d comments · %s)\n", item.Title, item.Score, item.Descendants, item.Age())
-		if item.URL != "" {
-			fmt.Printf("  %s\n", item.URL)
-		}
-		fmt.Println()
-		count++
+	return execute(t, threadData{Item: thread.Story, Comments: thread.Comments, Options: thread.Options})
+}
+
+// printUser prints a user profile and their recent submissions to stdout in plain text.
+func printUser(user *api.User, items []*api.Item) error {
+	t, err := formatTemplate(userTemplate)
+	if err != nil {
+		return err
 	}
-	return nil
+	return execute(t, userData{User: user, Submissions: items})
 }
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+)
+
+// captureStdout returns what f writes to stdout.
+func captureStdout(t *testing.T, f func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	err = f()
+	os.Stdout = old
+	w.Close()
+	s := <-out
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// setOutput sets --output and --format for the length of a test, with an
+// online client for staleNotice to ask.
+func setOutput(t *testing.T, out, tmpl string) {
+	t.Helper()
+	oldOutput, oldFormat, oldClient := output, format, client
+	t.Cleanup(func() { output, format, client = oldOutput, oldFormat, oldClient })
+	output, format, client = out, tmpl, api.New()
+}
+
+// checkGolden compares got with testdata/name.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	want, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from testdata/%s\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+// ago returns the Unix time d before now. The durations used sit well
+// inside the unit Age rounds them to, so the output is stable.
+func ago(d time.Duration) int64 { return time.Now().Add(-d).Unix() }
+
+func goldenStories() []*api.Item {
+	return []*api.Item{
+		{ID: 101, Type: "story", Title: "Show HN: A tiny HN client", URL: "https://example.com/hn", Score: 120,
+			Descendants: 34, By: "alice", Time: ago(3*time.Hour + 20*time.Minute)},
+		{ID: 102, Type: "story", Title: "Ask HN: What are you reading?", Score: 1, By: "bob",
+			Time: ago(25 * time.Minute)},
+		{ID: 103, Type: "story", Title: "Ünïcode — titles", URL: "https://example.org/a?b=c&d=e", Score: 7,
+			Descendants: 1, By: "carol", Time: ago(50 * time.Hour)},
+	}
+}
+
+func goldenThread() *api.Thread {
+	comment := func(id, depth int, by, text string, replies ...*api.Comment) *api.Comment {
+		return &api.Comment{
+			Item:    &api.Item{ID: id, Type: "comment", By: by, Text: text, Time: ago(2*time.Hour + 10*time.Minute)},
+			Depth:   depth,
+			Replies: replies,
+		}
+	}
+	deleted := comment(205, 0, "", "")
+	deleted.Deleted = true
+	deleted.Replies = []*api.Comment{comment(206, 1, "erin", "Still here.")}
+	dead := comment(207, 0, "mallory", "spam")
+	dead.Dead = true
+	return &api.Thread{
+		Story: &api.Item{ID: 200, Type: "story", Title: "Ask HN: Tabs or spaces?", Score: 42, Descendants: 5,
+			By: "dave", Time: ago(5*time.Hour + 5*time.Minute), Text: "Asking for a friend.<p>Both &amp; neither?"},
+		Comments: []*api.Comment{
+			comment(201, 0, "frank", "Tabs.<p>Obviously &quot;tabs&quot;.",
+				comment(202, 1, "grace", "Spaces, <i>always</i>.",
+					comment(203, 2, "heidi", "Why not both?"))),
+			comment(204, 0, "ivan", "Whatever gofmt says."),
+			deleted,
+			dead,
+		},
+	}
+}
+
+func goldenUser() (*api.User, []*api.Item) {
+	user := &api.User{ID: "alice", Karma: 4321, About: "Builds things. <a href=\"https://example.com\">example.com</a>"}
+	return user, []*api.Item{
+		{ID: 301, Type: "story", Title: "My first post", URL: "https://example.com/first", Score: 12,
+			Descendants: 3, Time: ago(4*24*time.Hour + time.Hour)},
+		{ID: 302, Type: "story", Title: "A text post", Score: 1, Time: ago(90 * time.Second)},
+	}
+}
+
+// The default plain output is pinned byte for byte to what hncli printed
+// before it was rendered from templates.
+func TestPlainOutputGolden(t *testing.T) {
+	setOutput(t, outputText, "")
+	checkGolden(t, "stories.golden", captureStdout(t, func() error { return emitStories(goldenStories()) }))
+	checkGolden(t, "thread.golden", captureStdout(t, func() error { return emitThread(goldenThread()) }))
+	checkGolden(t, "user.golden", captureStdout(t, func() error { return emitUser(goldenUser()) }))
+	checkGolden(t, "search.golden", captureStdout(t, func() error { return printSearchResults(goldenStories()) }))
+}
+
+func TestCustomFormat(t *testing.T) {
+	setOutput(t, outputText, `{{.Index}}\t{{.ID}}\t{{.Title | truncate 12}}\t{{hostname .URL}}`)
+	got := captureStdout(t, func() error { return emitStories(goldenStories()) })
+	want := "1\t101\tShow HN: A …\texample.com\n" +
+		"2\t102\tAsk HN: Wha…\t\n" +
+		"3\t103\tÜnïcode — t…\texample.org\n"
+	if got != want {
+		t.Errorf("--format output = %q, want %q", got, want)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "user.tmpl")
+	if err := os.WriteFile(path, []byte("{{.ID}} has {{.Karma}} karma and {{len .Submissions}} posts"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	format = "@" + path
+	if got := captureStdout(t, func() error { return emitUser(goldenUser()) }); got != "alice has 4321 karma and 2 posts\n" {
+		t.Errorf("--format @file output = %q", got)
+	}
+
+	format = "{{.Nope}}"
+	if err := emitStories(goldenStories()); err == nil {
+		t.Error("a template naming a missing field succeeded")
+	}
+}
+
+func TestStructuredStories(t *testing.T) {
+	stories := goldenStories()
+
+	setOutput(t, outputJSON, "")
+	var got []*api.Item
+	if err := json.Unmarshal([]byte(captureStdout(t, func() error { return emitStories(stories) })), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].ID != 101 || got[2].Title != stories[2].Title {
+		t.Errorf("JSON stories = %+v", got)
+	}
+	if out := captureStdout(t, func() error { return emitStories(nil) }); out != "[]\n" {
+		t.Errorf("JSON for no stories = %q, want []", out)
+	}
+
+	output = outputNDJSON
+	lines := strings.Split(strings.TrimSuffix(captureStdout(t, func() error { return emitStories(stories) }), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("NDJSON has %d lines, want one per story: %q", len(lines), lines)
+	}
+	for i, line := range lines {
+		var item api.Item
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			t.Fatalf("NDJSON line %d: %v", i+1, err)
+		}
+		if item.ID != stories[i].ID {
+			t.Errorf("NDJSON line %d has item %d, want %d", i+1, item.ID, stories[i].ID)
+		}
+	}
+	if out := captureStdout(t, func() error { return emitStories(nil) }); out != "" {
+		t.Errorf("NDJSON for no stories = %q, want nothing", out)
+	}
+}
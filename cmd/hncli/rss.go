@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/rss"
+	"github.com/hexadecimoose/hncli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// Story sources accepted by --source.
+const (
+	sourceFirebase = "firebase"
+	sourceRSS      = "rss"
+)
+
+// rssFeeds maps Firebase list names to the equivalent hnrss.org feeds.
+var rssFeeds = map[string]string{
+	"topstories":  "frontpage",
+	"newstories":  "newest",
+	"beststories": "best",
+	"askstories":  "ask",
+	"showstories": "show",
+	"jobstories":  "jobs",
+}
+
+var (
+	source     string
+	rssURL     string
+	rssFilters []string
+	rssClient  *rss.Client
+)
+
+// checkSource validates --source and the flags that depend on it, and sets
+// up the hnrss client.
+func checkSource() error {
+	switch source {
+	case sourceFirebase:
+		if len(rssFilters) > 0 {
+			return fmt.Errorf("--filter applies to hnrss feeds; use it with --source rss or hncli rss")
+		}
+	case sourceRSS:
+		if offline {
+			return fmt.Errorf("hnrss feeds are not cached and cannot be read with --offline")
+		}
+	default:
+		return fmt.Errorf("unknown source %q (want firebase or rss)", source)
+	}
+	var opts []rss.Option
+	if u := flagOrEnv(rssURL, "HNCLI_RSS_URL"); u != "" {
+		opts = append(opts, rss.WithBaseURL(u))
+	}
+	rssClient = rss.New(opts...)
+	return nil
+}
+
+// parseFilters turns key=value pairs into hnrss query parameters.
+func parseFilters(pairs []string) (url.Values, error) {
+	v := url.Values{}
+	for _, p := range pairs {
+		key, val, ok := strings.Cut(p, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid filter %q (want key=value, e.g. points=100)", p)
+		}
+		v.Add(key, val)
+	}
+	return v, nil
+}
+
+// rssLoader returns a loader for count stories from an hnrss feed.
+func rssLoader(feed string, filters url.Values) ui.Loader {
+	return func(ctx context.Context) ([]*api.Item, []int, error) {
+		entries, err := rssClient.FeedContext(ctx, feed, count, filters)
+		if err != nil {
+			return nil, nil, err
+		}
+		return rss.HNItems(entries), nil, nil
+	}
+}
+
+// runRSS prints an hnrss feed, or opens it in the TUI under title.
+func runRSS(ctx context.Context, title, feed string, filters url.Values) error {
+	load := rssLoader(feed, filters)
+	if isPlain() {
+		items, _, err := load(ctx)
+		if err != nil {
+			return err
+		}
+		return emitStories(items)
+	}
+	return ui.RunWithLoader(ctx, client, title, load, uiOptions()...)
+}
+
+var rssCmd = &cobra.Command{
+	Use:   "rss <feed> [key=value...]",
+	Short: "Stories from an hnrss.org feed",
+	Long: `Show stories from any hnrss.org feed (frontpage, newest, best, ask, show,
+jobs, polls, classic, ...). Trailing key=value arguments are passed to hnrss
+as filters, for example:
+
+  hncli rss newest points=100
+  hncli rss frontpage comments=50
+  hncli rss newest q=rust`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filters, err := parseFilters(slices.Concat(args[1:], rssFilters))
+		if err != nil {
+			return err
+		}
+		return runRSS(cmd.Context(), "hnrss · "+args[0], args[0], filters)
+	},
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchByDate      bool
+	searchAuthor      string
+	searchSince       string
+	searchUntil       string
+	searchMinPoints   int
+	searchMinComments int
+	searchType        string
+	searchDomain      string
+	searchPage        int
+	searchAll         bool
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search Hacker News via Algolia",
+	Long: `Search stories or comments through the Algolia HN search API.
+
+The query may be left out when --author or --domain narrows the search, e.g.
+
+  hncli search --author pg --by-date
+  hncli search rust --type comment --since 7d
+  hncli search --domain lwn.net --min-points 100 --all`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := searchOptions(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		load := func(ctx context.Context) ([]*api.Item, []int, error) {
+			if searchAll {
+				items, err := client.SearchAll(ctx, opts)
+				return items, nil, err
+			}
+			res, err := client.SearchPage(ctx, opts)
+			if err != nil {
+				return nil, nil, err
+			}
+			return res.Items, nil, nil
+		}
+		if !isPlain() {
+			return ui.RunWithLoader(cmd.Context(), client, searchTitle(opts), load, uiOptions()...)
+		}
+		items, _, err := load(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("search failed: %w", err)
+		}
+		return emitSearchResults(items)
+	},
+}
+
+func init() {
+	f := searchCmd.Flags()
+	f.BoolVar(&searchByDate, "by-date", false, "sort newest first instead of by relevance")
+	f.StringVar(&searchAuthor, "author", "", "only items by this user")
+	f.StringVar(&searchSince, "since", "", "only items posted since a date (2006-01-02), a time (RFC 3339) or a duration ago (36h, 7d, 2w)")
+	f.StringVar(&searchUntil, "until", "", "only items posted before a date, time or duration ago")
+	f.IntVar(&searchMinPoints, "min-points", 0, "only items with at least this many points")
+	f.IntVar(&searchMinComments, "min-comments", 0, "only stories with at least this many comments")
+	f.StringVar(&searchType, "type", "story", "item type: "+strings.Join(api.SearchTypes, ", "))
+	f.StringVar(&searchDomain, "domain", "", "only items linking to this site or its subdomains")
+	f.IntVar(&searchPage, "page", 1, "page of results to show, -n hits per page")
+	f.BoolVar(&searchAll, "all", false, "fetch every page of results (Algolia stops at 1000 hits)")
+}
+
+// searchOptions builds the search request from query and the search flags.
+func searchOptions(query string) (api.SearchOptions, error) {
+	opts := api.SearchOptions{
+		Query:       query,
+		ByDate:      searchByDate,
+		Author:      searchAuthor,
+		MinPoints:   searchMinPoints,
+		MinComments: searchMinComments,
+		Type:        searchType,
+		Domain:      searchDomain,
+		Page:        searchPage - 1,
+		HitsPerPage: count,
+	}
+	if query == "" && searchAuthor == "" && searchDomain == "" {
+		return opts, fmt.Errorf("search needs a query, --author or --domain")
+	}
+	if searchPage < 1 {
+		return opts, fmt.Errorf("--page must be at least 1")
+	}
+	var err error
+	if opts.Since, err = parseWhen(searchSince); err != nil {
+		return opts, fmt.Errorf("--since: %w", err)
+	}
+	if opts.Until, err = parseWhen(searchUntil); err != nil {
+		return opts, fmt.Errorf("--until: %w", err)
+	}
+	return opts, nil
+}
+
+// parseWhen parses a --since/--until value: a date, an RFC 3339 time, or a
+// duration before now such as 36h, 7d or 2w. An empty string is the zero time.
+func parseWhen(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if d, err := parseAgo(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want 2006-01-02, RFC 3339 or a duration like 7d)", s)
+}
+
+// parseAgo parses a duration, also accepting whole days (d) and weeks (w).
+func parseAgo(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		err = fmt.Errorf("invalid duration %q", s)
+	}
+	return d, err
+}
+
+// searchTitle is the TUI header for a search, e.g.
+// `Search: "rust" · comments by pg`.
+func searchTitle(opts api.SearchOptions) string {
+	title := "Search"
+	if opts.Query != "" {
+		title += fmt.Sprintf(": %q", opts.Query)
+	}
+	var parts []string
+	if opts.Type != "story" {
+		parts = append(parts, opts.Type)
+	}
+	if opts.Author != "" {
+		parts = append(parts, "by "+opts.Author)
+	}
+	if opts.Domain != "" {
+		parts = append(parts, "on "+opts.Domain)
+	}
+	if opts.ByDate {
+		parts = append(parts, "newest first")
+	}
+	if len(parts) > 0 {
+		title += " · " + strings.Join(parts, " ")
+	}
+	return title
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAgo(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "7d", want: 7 * 24 * time.Hour},
+		{in: "2w", want: 14 * 24 * time.Hour},
+		{in: "0d", want: 0},
+		{in: "36h", want: 36 * time.Hour},
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "-3d", wantErr: true},
+		{in: "-1h", wantErr: true},
+		{in: "1.5d", wantErr: true},
+		{in: "2w3d", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAgo(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseAgo(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseAgo(%q) = %s, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseWhen(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Time // for absolute times
+		ago     time.Duration
+		wantErr bool
+	}{
+		{in: ""},
+		{in: "2024-03-01", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)},
+		{in: "2024-03-01T12:30:00Z", want: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)},
+		{in: "7d", ago: 7 * 24 * time.Hour},
+		{in: "2w", ago: 14 * 24 * time.Hour},
+		{in: "1h30m", ago: 90 * time.Minute},
+		{in: "2024-13-01", wantErr: true},
+		{in: "01/03/2024", wantErr: true},
+		{in: "last week", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseWhen(tt.in)
+		switch {
+		case tt.wantErr:
+			if err == nil {
+				t.Errorf("parseWhen(%q) = %s, want an error", tt.in, got)
+			}
+		case err != nil:
+			t.Errorf("parseWhen(%q): %v", tt.in, err)
+		case tt.ago != 0:
+			if d := time.Since(got) - tt.ago; d < 0 || d > time.Minute {
+				t.Errorf("parseWhen(%q) = %s, want %s ago", tt.in, got, tt.ago)
+			}
+		case !got.Equal(tt.want):
+			t.Errorf("parseWhen(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/spf13/cobra"
+)
+
+// feedLists maps feed names accepted by sync to Firebase list names.
+var feedLists = map[string]string{
+	"top":  "topstories",
+	"new":  "newstories",
+	"best": "beststories",
+	"ask":  "askstories",
+	"show": "showstories",
+	"jobs": "jobstories",
+}
+
+var (
+	syncFeeds    []string
+	syncComments bool
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Prefetch feeds and their comment threads for reading with --offline",
+	Long: `Fetch the current story feeds, and every comment on each story, into the
+on-disk cache so that hncli --offline can show them later without a network.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if client.Offline() {
+			return fmt.Errorf("sync needs the network and cannot run with --offline")
+		}
+		if noCache {
+			return fmt.Errorf("sync fills the cache and cannot run with --no-cache")
+		}
+		seen := make(map[int]bool)
+		var stories []*api.Item
+		for _, feed := range syncFeeds {
+			list, ok := feedLists[feed]
+			if !ok {
+				return fmt.Errorf("unknown feed %q (want top, new, best, ask, show or jobs)", feed)
+			}
+			items, err := client.StoriesContext(cmd.Context(), list, count)
+			if err != nil {
+				return fmt.Errorf("syncing %s: %w", feed, err)
+			}
+			fmt.Printf("%-5s %d stories\n", feed+":", len(items))
+			for _, item := range items {
+				if !seen[item.ID] {
+					seen[item.ID] = true
+					stories = append(stories, item)
+				}
+			}
+		}
+		if !syncComments {
+			return nil
+		}
+
+		failed := 0
+		for i, story := range stories {
+			fmt.Printf("\rthreads: %d/%d", i+1, len(stories))
+			if len(story.Kids) == 0 {
+				continue
+			}
+			if _, err := client.ThreadContext(cmd.Context(), story.ID); err != nil {
+				if cmd.Context().Err() != nil {
+					fmt.Println()
+					return cmd.Context().Err()
+				}
+				failed++
+			}
+		}
+		fmt.Println()
+		if failed > 0 {
+			return fmt.Errorf("%d of %d threads failed to sync", failed, len(stories))
+		}
+		return nil
+	},
+}
+
+func init() {
+	syncCmd.Flags().StringSliceVar(&syncFeeds, "feeds", []string{"top", "new", "best", "ask", "show", "jobs"}, "feeds to fetch")
+	syncCmd.Flags().BoolVar(&syncComments, "comments", true, "also fetch every story's comment thread")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hexadecimoose/hncli/internal/ui"
+)
+
+// searchTabs holds the --tab saved searches, as name=query.
+var searchTabs []string
+
+// storyTabs returns a TUI tab for each feed, followed by one for each
+// saved search.
+func storyTabs() ([]ui.Tab, error) {
+	var tabs []ui.Tab
+	for _, f := range feeds {
+		load, err := storyLoader(f.list)
+		if err != nil {
+			return nil, err
+		}
+		title := f.title
+		if source == sourceRSS {
+			title += " (hnrss)"
+		}
+		tabs = append(tabs, ui.Tab{Name: f.name, Title: title, Loader: load})
+	}
+	for _, t := range searchTabs {
+		name, query, _ := strings.Cut(t, "=")
+		name, query = strings.TrimSpace(name), strings.TrimSpace(query)
+		if name == "" || query == "" {
+			return nil, fmt.Errorf("invalid --tab %q (want name=query, e.g. Rust=rust)", t)
+		}
+		tabs = append(tabs, ui.Tab{
+			Name:   name,
+			Title:  fmt.Sprintf("Search: %q", query),
+			Loader: ui.SearchLoader(client, query, count),
+		})
+	}
+	return tabs, nil
+}
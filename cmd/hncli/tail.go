@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// tailBatch is how many new items tail fetches at a time. When further
+// behind than this it catches up without waiting between batches.
+const tailBatch = 200
+
+// tailTypes are the item types tail can be asked to print.
+var tailTypes = []string{"story", "comment", "job", "poll"}
+
+var (
+	tailType     []string
+	tailMatch    string
+	tailCursor   string
+	tailInterval time.Duration
+)
+
+var tailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Print new stories and comments as they are posted",
+	Long: `Follow Hacker News like tail -f, printing stories and comments as they
+are posted. New item IDs are pushed over the maxitem event stream, or with
+--stream=false found by checking the maxitem endpoint every --interval.
+
+With --cursor, the ID of the last item seen is kept in a file, and a
+restarted tail picks up from there, printing everything posted meanwhile:
+
+  hncli tail --type story --match '(?i)rust|golang'
+  hncli tail --cursor ~/.hncli-tail --output ndjson >> hn.ndjson`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if output == outputJSON {
+			return fmt.Errorf("tail writes a stream; use --output ndjson")
+		}
+		if offline {
+			return fmt.Errorf("tail needs the network and cannot be used with --offline")
+		}
+		if tailInterval < time.Second {
+			return fmt.Errorf("--interval must be at least 1s")
+		}
+		for _, t := range tailType {
+			if !slices.Contains(tailTypes, t) {
+				return fmt.Errorf("unknown --type %q (want %s)", t, strings.Join(tailTypes, ", "))
+			}
+		}
+		var match *regexp.Regexp
+		if tailMatch != "" {
+			var err error
+			if match, err = regexp.Compile(tailMatch); err != nil {
+				return fmt.Errorf("--match: %w", err)
+			}
+		}
+		t, err := formatTemplate(tailTemplate)
+		if err != nil {
+			return err
+		}
+		emit := func(item *api.Item) error {
+			if !slices.Contains(tailType, item.Type) || item.Deleted || item.Dead {
+				return nil
+			}
+			if match != nil && !matchItem(match, item) {
+				return nil
+			}
+			if output == outputNDJSON {
+				return writeNDJSON(item)
+			}
+			return execute(t, item)
+		}
+		cursor, err := readCursor(tailCursor)
+		if err != nil {
+			return err
+		}
+		save := func(id int) error { return writeCursor(tailCursor, id) }
+		err = follow(cmd.Context(), client, cursor, tailInterval, emit, save)
+		if cmd.Context().Err() != nil {
+			return nil // interrupted, which is how tail normally ends
+		}
+		return err
+	},
+}
+
+func init() {
+	f := tailCmd.Flags()
+	f.StringSliceVar(&tailType, "type", []string{"story", "comment"}, "item types to print: "+strings.Join(tailTypes, ", "))
+	f.StringVar(&tailMatch, "match", "", "only print items whose title, text or URL match this regular expression, e.g. '(?i)rust'")
+	f.StringVar(&tailCursor, "cursor", "", "file keeping the ID of the last item seen, to resume from after a restart")
+	f.DurationVar(&tailInterval, "interval", 10*time.Second, "how often to check for new items (with --stream, how long to wait on a quiet stream)")
+}
+
+// follow calls emit for every item posted after cursor, or from now on if
+// cursor is 0, until ctx is done or emit fails, and save with the new
+// cursor after each batch. Fetch errors are reported and retried at the
+// next check, every interval.
+func follow(ctx context.Context, client *api.Client, cursor int, interval time.Duration, emit func(*api.Item) error, save func(int) error) error {
+	if cursor == 0 {
+		var err error
+		if cursor, err = client.MaxItemContext(ctx); err != nil {
+			return err
+		}
+		if err := save(cursor); err != nil {
+			return err
+		}
+	}
+	var maxItems <-chan int // nil, which never delivers, unless streaming
+	if stream {
+		maxItems = client.WatchMaxItemContext(ctx)
+	}
+	for {
+		items, next, err := client.NewItemsContext(ctx, cursor, tailBatch)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		for _, item := range items {
+			if err := emit(item); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "hncli: %v (retrying)\n", err)
+		}
+		caughtUp := next-cursor < tailBatch || err != nil
+		if next != cursor {
+			cursor = next
+			if err := save(cursor); err != nil {
+				return err
+			}
+		}
+		if caughtUp {
+			if err := wait(ctx, cursor, interval, maxItems); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// wait returns once there may be items after cursor: when maxItems, if
+// streaming, reports a larger ID, or after interval otherwise. The
+// interval is also waited for when the stream reports nothing new, as a
+// fallback in case it has stalled.
+func wait(ctx context.Context, cursor int, interval time.Duration, maxItems <-chan int) error {
+	t := time.NewTimer(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			return nil
+		case top, ok := <-maxItems:
+			if !ok {
+				maxItems = nil
+			} else if top > cursor {
+				return nil
+			}
+		}
+	}
+}
+
+// matchItem reports whether re matches the item's title, text or URL.
+func matchItem(re *regexp.Regexp, item *api.Item) bool {
+	return re.MatchString(item.Title) || re.MatchString(util.StripHTML(item.Text)) || re.MatchString(item.URL)
+}
+
+// readCursor returns the item ID kept in path, or 0 if path is empty or
+// does not exist yet.
+func readCursor(path string) (int, error) {
+	if path == "" {
+		return 0, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("cursor file %s does not hold an item ID", path)
+	}
+	return id, nil
+}
+
+// writeCursor keeps id in path, if set.
+func writeCursor(path string, id int) error {
+	if path == "" {
+		return nil
+	}
+	return writeAtomic(path, []byte(strconv.Itoa(id)+"\n"))
+}
+
+// writeAtomic replaces the file at path with data, so that an interrupted
+// write can't lose what it held.
+func writeAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	userStories  bool
+	userComments bool
+	userLimit    int
+)
+
+var userCmd = &cobra.Command{
+	Use:   "user <username>",
+	Short: "View a user's profile and recent submissions",
+	Long: `View a user's profile together with their stories, comments and polls.
+
+Plain output lists their most recent stories (polls and jobs included), or
+comments with --comments, or both with --stories --comments:
+
+  hncli user pg --comments --limit 20 --plain`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isPlain() {
+			return ui.RunUser(cmd.Context(), client, args[0], uiOptions()...)
+		}
+		if userLimit < 1 {
+			return fmt.Errorf("--limit must be at least 1")
+		}
+		user, err := client.UserContext(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if user == nil || user.ID == "" {
+			return fmt.Errorf("user %q not found", args[0])
+		}
+		items, err := recentSubmissions(cmd.Context(), client, user, userLimit)
+		if err != nil {
+			return err
+		}
+		return emitUser(user, items)
+	},
+}
+
+func init() {
+	f := userCmd.Flags()
+	f.BoolVar(&userStories, "stories", false, "list stories, polls and jobs (the default)")
+	f.BoolVar(&userComments, "comments", false, "list comments, with the story each was posted under")
+	f.IntVar(&userLimit, "limit", 10, "number of submissions to list (plain output)")
+}
+
+// wantSubmission reports whether item is of a kind asked for with
+// --stories and --comments.
+func wantSubmission(item *api.Item) bool {
+	if item.Type == "comment" {
+		return userComments
+	}
+	return userStories || !userComments
+}
+
+// recentSubmissions fetches up to n of the user's live submissions of the
+// kinds asked for, most recent first, a page at a time until enough have
+// turned up or their history runs out. Submissions that fail to load are
+// skipped; an error is only returned if nothing could be fetched.
+func recentSubmissions(ctx context.Context, client *api.Client, user *api.User, n int) ([]*api.Item, error) {
+	var items []*api.Item
+	var firstErr error
+	for offset := 0; offset < len(user.Submitted) && len(items) < n; offset += ui.UserPage {
+		page, err := client.SubmissionsContext(ctx, user, offset, ui.UserPage)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		for _, item := range page {
+			if len(items) < n && wantSubmission(item) {
+				items = append(items, item)
+			}
+		}
+	}
+	if len(items) == 0 && firstErr != nil {
+		return nil, fmt.Errorf("fetching submissions: %w", firstErr)
+	}
+	return items, nil
+}
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/util"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// minSearchInterval is the shortest --search-interval, to stay polite
+	// to Algolia.
+	minSearchInterval = 30 * time.Second
+
+	// searchLag is how far back each search looks before the previous one,
+	// since Algolia indexes new items after a delay.
+	searchLag = 30 * time.Minute
+
+	// seenFor is how long alerts are remembered to keep them from firing
+	// twice. It must be well over searchLag.
+	seenFor = 7 * 24 * time.Hour
+
+	// webhookTimeout bounds each --webhook request.
+	webhookTimeout = 10 * time.Second
+
+	// execTimeout bounds each --exec command.
+	execTimeout = time.Minute
+)
+
+var (
+	watchKeywords       []string
+	watchRegexps        []string
+	watchDomains        []string
+	watchAuthors        []string
+	watchType           []string
+	watchStatePath      string
+	watchInterval       time.Duration
+	watchSearchInterval time.Duration
+	watchExec           string
+	watchAppend         string
+	watchWebhook        string
+	watchNotify         bool
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Alert on new stories and comments matching keywords, domains or authors",
+	Long: `Watch new stories and comments as they are posted, like tail, and raise an
+alert for each one matching any of the rules. Alerts are printed, and can
+also run a command, be appended to a file, be POSTed to a webhook or pop up
+as desktop notifications.
+
+With --search-interval, Algolia is also searched for the keyword, domain
+and author rules, catching items posted while watch was not running.
+
+Items already alerted on, and where watching left off, are kept in the
+--state file, so a restarted watch neither repeats nor misses alerts.
+
+--exec is run with sh -c, with {} replaced by the item's HN URL (or the
+URL appended if there is no {}). The item's details are in the environment
+as HNCLI_ID, HNCLI_TYPE, HNCLI_BY, HNCLI_TITLE, HNCLI_URL, HNCLI_TEXT and
+HNCLI_RULE; quote them when used, as they hold whatever was posted:
+
+  hncli watch --keyword acme --domain acme.com --notify
+  hncli watch --keyword acme --exec 'echo "$HNCLI_TITLE" {} | mail -s HN me'
+  hncli watch --author dang --webhook https://hooks.example.com/hn`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if output == outputJSON {
+			return fmt.Errorf("watch writes a stream; use --output ndjson")
+		}
+		if offline {
+			return fmt.Errorf("watch needs the network and cannot be used with --offline")
+		}
+		if watchInterval < time.Second {
+			return fmt.Errorf("--interval must be at least 1s")
+		}
+		if watchSearchInterval != 0 && watchSearchInterval < minSearchInterval {
+			return fmt.Errorf("--search-interval must be 0 or at least %s", minSearchInterval)
+		}
+		for _, t := range watchType {
+			if !slices.Contains(tailTypes, t) {
+				return fmt.Errorf("unknown --type %q (want %s)", t, strings.Join(tailTypes, ", "))
+			}
+		}
+		rules, err := watchRules()
+		if err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return fmt.Errorf("nothing to watch for; give at least one --keyword, --regex, --domain or --author")
+		}
+		t, err := formatTemplate(watchTemplate)
+		if err != nil {
+			return err
+		}
+		path := watchStatePath
+		if path == "" {
+			dir, err := api.DefaultCacheDir()
+			if err != nil {
+				return fmt.Errorf("locating state directory: %w", err)
+			}
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+			path = filepath.Join(dir, "watch.state")
+		}
+		st, err := readWatchState(path)
+		if err != nil {
+			return err
+		}
+
+		w := &watcher{rules: rules, state: st, path: path}
+		w.actions = append(w.actions, func(ctx context.Context, a alert) error {
+			if output == outputNDJSON {
+				return writeNDJSON(a)
+			}
+			return execute(t, a)
+		})
+		if watchExec != "" {
+			w.actions = append(w.actions, runAlert)
+		}
+		if watchAppend != "" {
+			w.actions = append(w.actions, appendAlert)
+		}
+		if watchWebhook != "" {
+			w.actions = append(w.actions, postAlert)
+		}
+		if watchNotify {
+			w.actions = append(w.actions, notifyAlert)
+		}
+
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+		var wg sync.WaitGroup
+		var searchErr error
+		if watchSearchInterval > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if searchErr = w.search(ctx, client); searchErr != nil {
+					cancel()
+				}
+			}()
+		}
+		check := func(item *api.Item) error { return w.check(ctx, item) }
+		err = follow(ctx, client, w.cursor(), watchInterval, check, w.setCursor)
+		cancel()
+		wg.Wait()
+		if cmd.Context().Err() != nil {
+			return nil // interrupted, which is how watch normally ends
+		}
+		if searchErr != nil && !errors.Is(searchErr, context.Canceled) {
+			return searchErr
+		}
+		return err
+	},
+}
+
+func init() {
+	f := watchCmd.Flags()
+	f.StringArrayVar(&watchKeywords, "keyword", nil, "alert on items with this word or phrase in their title, text or URL, ignoring case (repeatable)")
+	f.StringArrayVar(&watchRegexps, "regex", nil, "alert on items whose title, text or URL match this regular expression (repeatable)")
+	f.StringArrayVar(&watchDomains, "domain", nil, "alert on stories linking to this domain or its subdomains (repeatable)")
+	f.StringArrayVar(&watchAuthors, "author", nil, "alert on items posted by this user (repeatable)")
+	f.StringSliceVar(&watchType, "type", []string{"story", "comment"}, "item types to watch: "+strings.Join(tailTypes, ", "))
+	f.StringVar(&watchStatePath, "state", "", "file keeping the alerts raised and where watching left off (default watch.state in the cache directory)")
+	f.DurationVar(&watchInterval, "interval", 10*time.Second, "how often to check for new items (with --stream, how long to wait on a quiet stream)")
+	f.DurationVar(&watchSearchInterval, "search-interval", 0, "also search Algolia this often (e.g. 5m) for keyword, domain and author rules; 0 turns it off")
+	f.StringVar(&watchExec, "exec", "", "run this sh -c command for each alert; {} is replaced by the item's HN URL")
+	f.StringVar(&watchAppend, "append", "", "append each alert as a JSON line to this file")
+	f.StringVar(&watchWebhook, "webhook", "", "POST each alert as JSON to this URL")
+	f.BoolVar(&watchNotify, "notify", false, "show a desktop notification for each alert")
+}
+
+// rule is one thing watch alerts on.
+type rule struct {
+	kind  string // keyword, regex, domain or author
+	value string
+	re    *regexp.Regexp // for keyword and regex rules
+}
+
+func (r rule) String() string { return r.kind + ":" + r.value }
+
+// match reports whether item satisfies the rule.
+func (r rule) match(item *api.Item) bool {
+	switch r.kind {
+	case "domain":
+		return item.OnDomain(r.value)
+	case "author":
+		return strings.EqualFold(item.By, r.value)
+	default:
+		return matchItem(r.re, item)
+	}
+}
+
+// searchOptions returns the Algolia search for items of type typ the rule
+// could match, and false if there is none: regular expressions can't be
+// searched for, and only stories link to a domain.
+func (r rule) searchOptions(typ string) (api.SearchOptions, bool) {
+	opts := api.SearchOptions{ByDate: true, Type: typ, HitsPerPage: 100}
+	switch r.kind {
+	case "keyword":
+		opts.Query = r.value
+	case "domain":
+		opts.Domain = r.value
+		return opts, typ == "story"
+	case "author":
+		opts.Author = r.value
+	default:
+		return opts, false
+	}
+	return opts, true
+}
+
+// watchRules builds the rules given by flags.
+func watchRules() ([]rule, error) {
+	var rules []rule
+	for _, k := range watchKeywords {
+		if strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("--keyword must not be empty")
+		}
+		// \b would not work for keywords such as "C++" that start or end
+		// with punctuation.
+		re := regexp.MustCompile(`(?i)(?:^|\W)` + regexp.QuoteMeta(k) + `(?:\W|$)`)
+		rules = append(rules, rule{kind: "keyword", value: k, re: re})
+	}
+	for _, expr := range watchRegexps {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("--regex: %w", err)
+		}
+		rules = append(rules, rule{kind: "regex", value: expr, re: re})
+	}
+	for _, d := range watchDomains {
+		rules = append(rules, rule{kind: "domain", value: d})
+	}
+	for _, a := range watchAuthors {
+		rules = append(rules, rule{kind: "author", value: a})
+	}
+	return rules, nil
+}
+
+// alert is an item that matched a rule. It is written out as the item's
+// JSON with the rule added.
+type alert struct {
+	*api.Item
+	Rule string `json:"rule"`
+}
+
+// watchState is what watch keeps between runs.
+type watchState struct {
+	Cursor   int           `json:"cursor"`   // last item ID looked at
+	Searched int64         `json:"searched"` // Unix time of the last search, 0 if none
+	Seen     map[int]int64 `json:"seen"`     // item ID → Unix time alerted on
+}
+
+// readWatchState returns the state kept in path, or an empty state if it
+// does not exist yet.
+func readWatchState(path string) (watchState, error) {
+	st := watchState{Seen: make(map[int]int64)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(b, &st); err != nil {
+		return st, fmt.Errorf("state file %s: %w", path, err)
+	}
+	if st.Seen == nil {
+		st.Seen = make(map[int]int64)
+	}
+	return st, nil
+}
+
+// watcher raises alerts for the items it is shown. It is shared between
+// the new item and search loops.
+type watcher struct {
+	rules   []rule
+	actions []func(context.Context, alert) error
+	act     sync.Mutex // held while an alert's actions run, so that they don't interleave
+
+	mu    sync.Mutex
+	state watchState
+	path  string // where state is saved
+}
+
+func (w *watcher) cursor() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.state.Cursor
+}
+
+func (w *watcher) setCursor(id int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.state.Cursor = id
+	return w.save()
+}
+
+// save writes the state out, forgetting alerts older than seenFor. w.mu
+// must be held.
+func (w *watcher) save() error {
+	old := time.Now().Add(-seenFor).Unix()
+	for id, t := range w.state.Seen {
+		if t < old {
+			delete(w.state.Seen, id)
+		}
+	}
+	b, err := json.Marshal(w.state)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(w.path, b)
+}
+
+// check raises an alert for item if it matches a rule and has not been
+// alerted on before. Actions that fail are reported and not retried; they
+// are stopped when ctx is done.
+func (w *watcher) check(ctx context.Context, item *api.Item) error {
+	if !slices.Contains(watchType, item.Type) || item.Deleted || item.Dead {
+		return nil
+	}
+	i := slices.IndexFunc(w.rules, func(r rule) bool { return r.match(item) })
+	if i < 0 {
+		return nil
+	}
+	w.mu.Lock()
+	if _, ok := w.state.Seen[item.ID]; ok {
+		w.mu.Unlock()
+		return nil
+	}
+	w.state.Seen[item.ID] = time.Now().Unix()
+	err := w.save()
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Actions run without w.mu, so a slow one holds up only other alerts.
+	w.act.Lock()
+	defer w.act.Unlock()
+	a := alert{Item: item, Rule: w.rules[i].String()}
+	for j, act := range w.actions {
+		if err := act(ctx, a); err != nil {
+			if j == 0 {
+				return err // printing failed, e.g. a closed pipe
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "hncli: alert on %d: %v\n", item.ID, err)
+		}
+	}
+	return nil
+}
+
+// search runs the Algolia searches for the rules every --search-interval
+// until ctx is done. Failed searches are reported and retried next time.
+func (w *watcher) search(ctx context.Context, client *api.Client) error {
+	w.mu.Lock()
+	last := w.state.Searched
+	w.mu.Unlock()
+	if last == 0 {
+		// Nothing before the first run is alerted on.
+		if err := w.setSearched(time.Now().Unix()); err != nil {
+			return err
+		}
+	}
+	t := time.NewTicker(watchSearchInterval)
+	defer t.Stop()
+	for {
+		if last > 0 {
+			start := time.Now().Unix()
+			since := time.Unix(last, 0).Add(-searchLag)
+			ok := true
+			for _, r := range w.rules {
+				for _, typ := range watchType {
+					opts, searchable := r.searchOptions(typ)
+					if !searchable {
+						continue
+					}
+					opts.Since = since
+					items, err := client.SearchAll(ctx, opts)
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "hncli: searching for %s: %v (retrying)\n", r, err)
+						ok = false
+					}
+					for _, item := range items {
+						if err := w.check(ctx, item); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			if ok {
+				if err := w.setSearched(start); err != nil {
+					return err
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+		w.mu.Lock()
+		last = w.state.Searched
+		w.mu.Unlock()
+	}
+}
+
+func (w *watcher) setSearched(t int64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.state.Searched = t
+	return w.save()
+}
+
+// runAlert runs --exec for a, killing it if it takes over execTimeout.
+func runAlert(ctx context.Context, a alert) error {
+	ctx, cancel := context.WithTimeout(ctx, execTimeout)
+	defer cancel()
+	url, _ := hnURL(a.ID)
+	cmd := util.ShellCommandContext(ctx, watchExec, url)
+	cmd.Env = append(os.Environ(),
+		"HNCLI_ID="+strconv.Itoa(a.ID),
+		"HNCLI_TYPE="+a.Type,
+		"HNCLI_BY="+a.By,
+		"HNCLI_TITLE="+a.Title,
+		"HNCLI_URL="+a.URL,
+		"HNCLI_TEXT="+util.StripHTML(a.Text),
+		"HNCLI_RULE="+a.Rule,
+	)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr // stdout is for alerts
+	return cmd.Run()
+}
+
+// appendAlert appends a as a JSON line to --append.
+func appendAlert(ctx context.Context, a alert) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(watchAppend, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// postAlert POSTs a as JSON to --webhook.
+func postAlert(ctx context.Context, a alert) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, watchWebhook, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "hncli/"+version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// notifyAlert shows a desktop notification for a.
+func notifyAlert(ctx context.Context, a alert) error {
+	body := a.Title
+	if a.Type == "comment" {
+		body = a.By + ": " + util.Truncate(strings.Join(strings.Fields(util.StripHTML(a.Text)), " "), 200)
+	}
+	return util.Notify("HN: "+a.Rule, body)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/api/apitest"
+)
+
+// setWatchFlags sets the watch rule flags for the length of a test.
+func setWatchFlags(t *testing.T, keywords, domains, authors []string) {
+	t.Helper()
+	old := [][]string{watchKeywords, watchRegexps, watchDomains, watchAuthors}
+	t.Cleanup(func() {
+		watchKeywords, watchRegexps, watchDomains, watchAuthors = old[0], old[1], old[2], old[3]
+	})
+	watchKeywords, watchRegexps, watchDomains, watchAuthors = keywords, nil, domains, authors
+}
+
+func TestWatchRulesKeywords(t *testing.T) {
+	tests := []struct {
+		keyword string
+		title   string
+		want    bool
+	}{
+		{"go", "Go 1.24 is released", true},
+		{"go", "Why I left Google", false},
+		{"go", "Writing a compiler in go.", true},
+		{"C++", "Modern C++ in 2025", true},
+		{"C++", "What's new in C++?", true},
+		{"C++", "C+ grades", false},
+		{"rust", "RUST in the kernel", true},
+		{"rust", "Trusted computing", false},
+		{"open source", "An open source database", true},
+		{"open source", "Open sourced today", false},
+	}
+	for _, tt := range tests {
+		setWatchFlags(t, []string{tt.keyword}, nil, nil)
+		rules, err := watchRules()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rules[0].match(&api.Item{Type: "story", Title: tt.title}); got != tt.want {
+			t.Errorf("keyword %q on %q: match = %v, want %v", tt.keyword, tt.title, got, tt.want)
+		}
+	}
+
+	setWatchFlags(t, []string{" "}, nil, nil)
+	if _, err := watchRules(); err == nil {
+		t.Error("watchRules accepted an empty --keyword")
+	}
+}
+
+func TestRuleMatchDomainAndAuthor(t *testing.T) {
+	setWatchFlags(t, nil, []string{"www.example.com"}, []string{"Dang"})
+	rules, err := watchRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	domain, author := rules[0], rules[1]
+
+	tests := []struct {
+		r    rule
+		item api.Item
+		want bool
+	}{
+		{domain, api.Item{Type: "story", URL: "https://example.com/post"}, true},
+		{domain, api.Item{Type: "story", URL: "https://blog.Example.com/post"}, true},
+		{domain, api.Item{Type: "story", URL: "https://notexample.com/"}, false},
+		{domain, api.Item{Type: "story", Title: "example.com is down"}, false},
+		{domain, api.Item{Type: "comment", StoryURL: "https://example.com/a"}, true},
+		{author, api.Item{Type: "comment", By: "dang"}, true},
+		{author, api.Item{Type: "story", By: "dangus"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.r.match(&tt.item); got != tt.want {
+			t.Errorf("%s on %+v: match = %v, want %v", tt.r, tt.item, got, tt.want)
+		}
+	}
+}
+
+// newTestWatcher returns a watcher for the rules given by flags that
+// records its alerts, with its state in dir.
+func newTestWatcher(t *testing.T, dir string) (*watcher, *[]int) {
+	t.Helper()
+	rules, err := watchRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "watch.state")
+	st, err := readWatchState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var alerted []int
+	w := &watcher{rules: rules, state: st, path: path}
+	w.actions = append(w.actions, func(ctx context.Context, a alert) error {
+		alerted = append(alerted, a.ID)
+		return nil
+	})
+	return w, &alerted
+}
+
+func TestWatcherDedupe(t *testing.T) {
+	setWatchFlags(t, []string{"acme"}, nil, nil)
+	dir := t.TempDir()
+	ctx := context.Background()
+	match := &api.Item{ID: 7, Type: "story", Title: "Acme raises prices"}
+
+	w, alerted := newTestWatcher(t, dir)
+	for _, item := range []*api.Item{
+		match,
+		{ID: 8, Type: "story", Title: "Nothing to see"},
+		{ID: 9, Type: "story", Title: "Acme is hiring", Dead: true},
+		{ID: 10, Type: "job", Title: "Acme is hiring"}, // not a --type watched
+		match,
+	} {
+		if err := w.check(ctx, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.setCursor(10); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(*alerted, []int{7}) {
+		t.Errorf("alerted on %v, want [7] once", *alerted)
+	}
+
+	// A restarted watch picks up where the last left off.
+	w, alerted = newTestWatcher(t, dir)
+	if w.cursor() != 10 {
+		t.Errorf("cursor after restart = %d, want 10", w.cursor())
+	}
+	if err := w.check(ctx, match); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.check(ctx, &api.Item{ID: 11, Type: "comment", Text: "<p>acme again"}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(*alerted, []int{11}) {
+		t.Errorf("alerted after restart on %v, want only the new item [11]", *alerted)
+	}
+
+	// Alerts are forgotten once they are older than seenFor.
+	w.mu.Lock()
+	w.state.Seen[7] = time.Now().Add(-seenFor - time.Hour).Unix()
+	err := w.save()
+	w.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := readWatchState(w.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := st.Seen[7]; ok || len(st.Seen) != 1 {
+		t.Errorf("seen after pruning = %v, want only item 11", st.Seen)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	oldStream := stream
+	stream = false
+	t.Cleanup(func() { stream = oldStream })
+
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(&api.Item{ID: 1, Type: "story"}, &api.Item{ID: 2, Type: "comment"}, &api.Item{ID: 3, Type: "story"})
+	c := srv.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		mu      sync.Mutex
+		emitted []int
+		saved   []int
+	)
+	emit := func(item *api.Item) error {
+		mu.Lock()
+		defer mu.Unlock()
+		emitted = append(emitted, item.ID)
+		switch item.ID {
+		case 3:
+			go srv.AddItems(&api.Item{ID: 4, Type: "comment"}) // posted while following
+		case 4:
+			cancel()
+		}
+		return nil
+	}
+	save := func(cursor int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		saved = append(saved, cursor)
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- follow(ctx, c, 1, 10*time.Millisecond, emit, save) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("follow = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("follow never saw item 4")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(emitted, []int{2, 3, 4}) {
+		t.Errorf("emitted %v, want [2 3 4]", emitted)
+	}
+	if len(saved) == 0 || saved[0] != 3 {
+		t.Errorf("saved cursors %v, want 3 first", saved)
+	}
+}
+
+func TestRunAlertStopsWithContext(t *testing.T) {
+	old := watchExec
+	watchExec = "exec sleep 10 #"
+	t.Cleanup(func() { watchExec = old })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := runAlert(ctx, alert{Item: &api.Item{ID: 1, Type: "story"}}); err == nil {
+		t.Error("runAlert succeeded after its context ended")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("runAlert took %s, want it stopped with its context", d)
+	}
+}
@@ -0,0 +1,526 @@
+// Package apitest provides a fake Hacker News API server for tests.
+//
+// The server speaks enough of the Firebase and Algolia APIs for an
+// api.Client to run against it: items, users, story lists, updates and
+// maxitem under /v0, and search and item trees under /api/v1. Tests
+// populate it with the Add and Set methods and point a client at it with
+// Client.
+//
+// Any /v0 path can also be watched as a Firebase event stream: asked for
+// with Accept: text/event-stream, the server sends a put of the whole value
+// and another each time a change to the data alters it.
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+)
+
+// Server is a fake HN API backed by in-memory data.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	items    map[int]*api.Item
+	users    map[string]*api.User
+	lists    map[string][]int
+	changed  []int            // IDs of items stored, most recent last, for updates.json
+	profiles []string         // IDs of users stored, most recent last
+	failures map[string][]int // path → status codes to return before succeeding
+	requests map[string]int   // path → number of requests served
+
+	changes chan struct{} // closed and replaced whenever data changes
+	drop    chan struct{} // closed and replaced to end the open event streams
+	closed  chan struct{} // closed by Close
+}
+
+// NewServer starts a fake HN API server. Callers should Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		items:    make(map[int]*api.Item),
+		users:    make(map[string]*api.User),
+		lists:    make(map[string][]int),
+		failures: make(map[string][]int),
+		requests: make(map[string]int),
+		changes:  make(chan struct{}),
+		drop:     make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v0/item/{file}", s.handleItem)
+	mux.HandleFunc("GET /v0/user/{file}", s.handleUser)
+	mux.HandleFunc("GET /v0/updates.json", s.handleUpdates)
+	mux.HandleFunc("GET /v0/maxitem.json", s.handleMaxItem)
+	mux.HandleFunc("GET /v0/{file}", s.handleList)
+	mux.HandleFunc("GET /api/v1/search", s.handleSearch)
+	mux.HandleFunc("GET /api/v1/search_by_date", s.handleSearch)
+	mux.HandleFunc("GET /api/v1/items/{id}", s.handleAlgoliaItem)
+	s.Server = httptest.NewServer(s.intercept(s.events(mux)))
+	return s
+}
+
+// Close ends any open event streams and shuts the server down.
+func (s *Server) Close() {
+	close(s.closed)
+	s.Server.Close()
+}
+
+// BaseURL returns the Firebase API root, for api.WithBaseURL.
+func (s *Server) BaseURL() string { return s.URL + "/v0" }
+
+// AlgoliaURL returns the Algolia API root, for api.WithAlgoliaURL.
+func (s *Server) AlgoliaURL() string { return s.URL + "/api/v1" }
+
+// Client returns an api.Client pointed at the server, without rate
+// limiting or retry delays. opts are applied after the defaults.
+func (s *Server) Client(opts ...api.Option) *api.Client {
+	base := []api.Option{
+		api.WithBaseURL(s.BaseURL()),
+		api.WithAlgoliaURL(s.AlgoliaURL()),
+		api.WithHTTPClient(s.Server.Client()),
+		api.WithRateLimit(0, 0),
+		api.WithRetry(api.RetryPolicy{MaxRetries: 3}),
+	}
+	return api.New(append(base, opts...)...)
+}
+
+// AddItems stores items, replacing any with the same IDs. They are
+// reported by updates.json as changed.
+func (s *Server) AddItems(items ...*api.Item) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range items {
+		s.items[item.ID] = item
+		s.changed = append(slices.DeleteFunc(s.changed, func(id int) bool { return id == item.ID }), item.ID)
+	}
+	s.notify()
+}
+
+// AddUsers stores users, replacing any with the same IDs. They are
+// reported by updates.json as changed.
+func (s *Server) AddUsers(users ...*api.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range users {
+		s.users[u.ID] = u
+		s.profiles = append(slices.DeleteFunc(s.profiles, func(id string) bool { return id == u.ID }), u.ID)
+	}
+	s.notify()
+}
+
+// SetList sets the IDs returned by a named list such as "topstories".
+func (s *Server) SetList(name string, ids ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lists[name] = ids
+	s.notify()
+}
+
+// DropStreams ends every open event stream, as a flaky connection or a
+// restarting server would. Clients are expected to reconnect.
+func (s *Server) DropStreams() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.drop)
+	s.drop = make(chan struct{})
+}
+
+// notify wakes the open event streams after a change. s.mu must be held.
+func (s *Server) notify() {
+	close(s.changes)
+	s.changes = make(chan struct{})
+}
+
+// FailNext makes the next len(codes) requests for path fail with the given
+// status codes, in order. path is the request path, e.g. "/v0/item/1.json".
+func (s *Server) FailNext(path string, codes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], codes...)
+}
+
+// Requests returns how many requests have been made for path, including
+// failed ones.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// intercept counts requests and serves injected failures before handing
+// the request to next.
+func (s *Server) intercept(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		var code int
+		if f := s.failures[r.URL.Path]; len(f) > 0 {
+			code, s.failures[r.URL.Path] = f[0], f[1:]
+		}
+		s.mu.Unlock()
+		if code != 0 {
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// events serves /v0 requests asking for text/event-stream as Firebase does,
+// sending what next answers for a plain GET whenever that changes.
+func (s *Server) events(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v0/") || r.Header.Get("Accept") != "text/event-stream" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		get := func() (int, []byte) {
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, r.URL.Path, nil))
+			return rec.Code, bytes.TrimSpace(rec.Body.Bytes())
+		}
+
+		s.mu.Lock()
+		changes, drop := s.changes, s.drop
+		s.mu.Unlock()
+		code, body := get()
+		if code != http.StatusOK {
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		var last []byte
+		for {
+			if !bytes.Equal(body, last) {
+				fmt.Fprintf(w, "event: put\ndata: {\"path\":\"/\",\"data\":%s}\n\n", body)
+				flusher.Flush()
+				last = body
+			}
+			select {
+			case <-changes:
+			case <-drop:
+				return
+			case <-s.closed:
+				return
+			case <-r.Context().Done():
+				return
+			}
+			s.mu.Lock()
+			changes, drop = s.changes, s.drop
+			s.mu.Unlock()
+			_, body = get()
+		}
+	})
+}
+
+// writeJSON writes v as a JSON response. A nil v is written as null, which
+// is how Firebase answers for items and users that don't exist.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v) //nolint:errcheck
+}
+
+func (s *Server) handleItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimSuffix(r.PathValue("file"), ".json"))
+	if err != nil {
+		http.Error(w, "bad item id", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	item := s.items[id]
+	s.mu.Unlock()
+	if item == nil {
+		writeJSON(w, nil)
+		return
+	}
+	writeJSON(w, item)
+}
+
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	u := s.users[strings.TrimSuffix(r.PathValue("file"), ".json")]
+	s.mu.Unlock()
+	if u == nil {
+		writeJSON(w, nil)
+		return
+	}
+	writeJSON(w, u)
+}
+
+// maxUpdates is how many changed items and profiles updates.json reports.
+const maxUpdates = 100
+
+// handleUpdates reports the most recently stored items and users, newest
+// first.
+func (s *Server) handleUpdates(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	u := api.Updates{Items: []int{}, Profiles: []string{}}
+	for i := len(s.changed) - 1; i >= 0 && len(u.Items) < maxUpdates; i-- {
+		u.Items = append(u.Items, s.changed[i])
+	}
+	for i := len(s.profiles) - 1; i >= 0 && len(u.Profiles) < maxUpdates; i-- {
+		u.Profiles = append(u.Profiles, s.profiles[i])
+	}
+	s.mu.Unlock()
+	writeJSON(w, u)
+}
+
+// handleMaxItem reports the largest item ID stored.
+func (s *Server) handleMaxItem(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	id := 0
+	for k := range s.items {
+		id = max(id, k)
+	}
+	s.mu.Unlock()
+	writeJSON(w, id)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	ids, ok := s.lists[strings.TrimSuffix(r.PathValue("file"), ".json")]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, ids)
+}
+
+// algoliaHit mirrors the fields of an Algolia search hit the client reads.
+type algoliaHit struct {
+	ObjectID    string   `json:"objectID"`
+	Tags        []string `json:"_tags"`
+	Title       string   `json:"title,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Author      string   `json:"author"`
+	Points      int      `json:"points"`
+	NumComments int      `json:"num_comments"`
+	CreatedAtI  int64    `json:"created_at_i"`
+	CommentText string   `json:"comment_text,omitempty"`
+	ParentID    int      `json:"parent_id,omitempty"`
+	StoryID     int      `json:"story_id,omitempty"`
+	StoryTitle  string   `json:"story_title,omitempty"`
+	StoryURL    string   `json:"story_url,omitempty"`
+}
+
+// handleSearch serves both search endpoints. Stories match on their title
+// (or URL, with restrictSearchableAttributes=url) and comments on their
+// text, ignoring case. tags and numericFilters are applied as ANDed lists.
+// /search returns hits in ID order, /search_by_date newest first.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := strings.ToLower(params.Get("query"))
+	n, err := strconv.Atoi(params.Get("hitsPerPage"))
+	if err != nil || n <= 0 {
+		n = 20
+	}
+	page, _ := strconv.Atoi(params.Get("page"))
+	var tags []string
+	if t := params.Get("tags"); t != "" {
+		tags = strings.Split(t, ",")
+	}
+	var numeric []string
+	if f := params.Get("numericFilters"); f != "" {
+		numeric = strings.Split(f, ",")
+	}
+
+	s.mu.Lock()
+	var hits []algoliaHit
+	for _, item := range s.items {
+		if item.Deleted || item.Dead {
+			continue
+		}
+		h := s.algoliaHit(item)
+		text := h.Title
+		switch {
+		case item.Type == "comment":
+			text = item.Text
+		case params.Get("restrictSearchableAttributes") == "url":
+			text = item.URL
+		}
+		if strings.Contains(strings.ToLower(text), q) && hasTags(h, tags) && matchNumeric(h, numeric) {
+			hits = append(hits, h)
+		}
+	}
+	s.mu.Unlock()
+	if strings.HasSuffix(r.URL.Path, "_by_date") {
+		slices.SortFunc(hits, func(a, b algoliaHit) int { return int(b.CreatedAtI - a.CreatedAtI) })
+	} else {
+		slices.SortFunc(hits, func(a, b algoliaHit) int {
+			x, _ := strconv.Atoi(a.ObjectID)
+			y, _ := strconv.Atoi(b.ObjectID)
+			return x - y
+		})
+	}
+
+	total := len(hits)
+	lo, hi := min(page*n, total), min((page+1)*n, total)
+	writeJSON(w, map[string]any{
+		"hits":    append([]algoliaHit{}, hits[lo:hi]...),
+		"page":    page,
+		"nbPages": (total + n - 1) / n,
+		"nbHits":  total,
+	})
+}
+
+// algoliaHit builds the search hit for item, tagged the way Algolia tags
+// it. s.mu must be held.
+func (s *Server) algoliaHit(item *api.Item) algoliaHit {
+	h := algoliaHit{
+		ObjectID:    strconv.Itoa(item.ID),
+		Tags:        []string{item.Type, "author_" + item.By},
+		Title:       item.Title,
+		URL:         item.URL,
+		Author:      item.By,
+		Points:      item.Score,
+		NumComments: item.Descendants,
+		CreatedAtI:  item.Time,
+	}
+	switch {
+	case strings.HasPrefix(item.Title, "Ask HN:"):
+		h.Tags = append(h.Tags, "ask_hn")
+	case strings.HasPrefix(item.Title, "Show HN:"):
+		h.Tags = append(h.Tags, "show_hn")
+	}
+	if item.Type == "comment" {
+		h.CommentText = item.Text
+		h.ParentID = item.Parent
+		story := item
+		for story.Parent != 0 && s.items[story.Parent] != nil {
+			story = s.items[story.Parent]
+		}
+		h.StoryID, h.StoryTitle, h.StoryURL = story.ID, story.Title, story.URL
+	}
+	return h
+}
+
+// hasTags reports whether h carries every tag in tags.
+func hasTags(h algoliaHit, tags []string) bool {
+	for _, t := range tags {
+		if !slices.Contains(h.Tags, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchNumeric reports whether h satisfies every filter in filters, each of
+// the form "attribute<op>value".
+func matchNumeric(h algoliaHit, filters []string) bool {
+	for _, f := range filters {
+		i := strings.IndexAny(f, "<>=!")
+		if i < 0 {
+			return false
+		}
+		j := i + strings.LastIndexAny(f[i:], "<>=") + 1
+		want, err := strconv.ParseInt(f[j:], 10, 64)
+		if err != nil {
+			return false
+		}
+		var got int64
+		switch f[:i] {
+		case "created_at_i":
+			got = h.CreatedAtI
+		case "points":
+			got = int64(h.Points)
+		case "num_comments":
+			got = int64(h.NumComments)
+		default:
+			return false
+		}
+		ok := false
+		switch f[i:j] {
+		case "<":
+			ok = got < want
+		case "<=":
+			ok = got <= want
+		case ">":
+			ok = got > want
+		case ">=":
+			ok = got >= want
+		case "=":
+			ok = got == want
+		case "!=":
+			ok = got != want
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// algoliaItem mirrors a node of Algolia's items endpoint.
+type algoliaItem struct {
+	ID         int           `json:"id"`
+	CreatedAtI int64         `json:"created_at_i"`
+	Type       string        `json:"type"`
+	Author     *string       `json:"author"`
+	Title      string        `json:"title,omitempty"`
+	URL        string        `json:"url,omitempty"`
+	Text       *string       `json:"text"`
+	Points     *int          `json:"points"`
+	ParentID   *int          `json:"parent_id"`
+	Children   []algoliaItem `json:"children"`
+}
+
+// handleAlgoliaItem serves an item and all its descendants as one tree.
+func (s *Server) handleAlgoliaItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "bad item id", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.items[id] == nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, s.algoliaTree(id))
+}
+
+// algoliaTree builds the Algolia form of item id. Deleted comments keep
+// their place with a null author and text, as on the real service.
+// s.mu must be held.
+func (s *Server) algoliaTree(id int) algoliaItem {
+	item := s.items[id]
+	a := algoliaItem{
+		ID:         item.ID,
+		CreatedAtI: item.Time,
+		Type:       item.Type,
+		Title:      item.Title,
+		URL:        item.URL,
+		Children:   []algoliaItem{},
+	}
+	if !item.Deleted {
+		a.Author, a.Text = &item.By, &item.Text
+	}
+	if item.Type != "comment" {
+		a.Points = &item.Score
+	}
+	if item.Parent != 0 {
+		a.ParentID = &item.Parent
+	}
+	for _, kid := range item.Kids {
+		if s.items[kid] != nil {
+			a.Children = append(a.Children, s.algoliaTree(kid))
+		}
+	}
+	return a
+}
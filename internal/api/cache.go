@@ -0,0 +1,294 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cache is an on-disk store of raw API responses keyed by request URL.
+// Each entry is a small JSON file recording when it was fetched and when it
+// expires; the expiry is chosen per endpoint when the entry is written.
+type Cache struct {
+	dir string
+	ttl time.Duration // overrides the per-endpoint TTLs when non-zero
+}
+
+// cacheEntry is the on-disk form of a cached response.
+type cacheEntry struct {
+	URL     string          `json:"url"`
+	Fetched time.Time       `json:"fetched"`
+	Expires time.Time       `json:"expires"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// CacheStats summarises the contents of a cache directory.
+type CacheStats struct {
+	Dir     string
+	Entries int
+	Expired int
+	Bytes   int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+// DefaultCacheDir returns $XDG_CACHE_HOME/hncli, falling back to the
+// platform's user cache directory.
+func DefaultCacheDir() (string, error) {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		var err error
+		if base, err = os.UserCacheDir(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(base, "hncli"), nil
+}
+
+// NewCache returns a cache stored in dir. If ttl is non-zero, entries older
+// than ttl count as expired, in place of the per-endpoint TTLs they were
+// stored with.
+func NewCache(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl}
+}
+
+// ErrNotCached is returned in offline mode for requests that were never cached.
+var ErrNotCached = errors.New("not available offline")
+
+// Offline reports whether the client is in offline mode.
+func (c *Client) Offline() bool { return c.offline }
+
+// StaleSince returns when the oldest response served in offline mode was
+// originally fetched, or the zero time if none has been served yet.
+func (c *Client) StaleSince() time.Time {
+	c.staleMu.Lock()
+	defer c.staleMu.Unlock()
+	return c.staleSince
+}
+
+// getOffline decodes the cached response for url into v, whether or not it
+// has expired, and records how old it is.
+func (c *Client) getOffline(url string, v any) error {
+	if c.cache == nil {
+		return fmt.Errorf("%w: no cache configured", ErrNotCached)
+	}
+	e, err := c.cache.read(url)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotCached, url)
+	}
+	c.staleMu.Lock()
+	if c.staleSince.IsZero() || e.Fetched.Before(c.staleSince) {
+		c.staleSince = e.Fetched
+	}
+	c.staleMu.Unlock()
+	return json.Unmarshal(e.Body, v)
+}
+
+// Dir returns the directory the cache is stored in.
+func (c *Cache) Dir() string { return c.dir }
+
+// path returns the file an entry for url is stored in.
+func (c *Cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// read loads the entry for url, whether or not it has expired.
+func (c *Cache) read(url string) (*cacheEntry, error) {
+	b, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return nil, err
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, err
+	}
+	if e.URL != url {
+		return nil, errors.New("cache: key collision")
+	}
+	return &e, nil
+}
+
+// Get returns the cached body for url if a fresh entry exists.
+func (c *Cache) Get(url string) ([]byte, bool) {
+	e, err := c.read(url)
+	if err != nil || c.expired(e, time.Now()) {
+		return nil, false
+	}
+	return e.Body, true
+}
+
+// Put stores body as the response for url. The file is written atomically
+// so concurrent readers never see a partial entry.
+func (c *Cache) Put(url string, body []byte) error {
+	if !json.Valid(body) {
+		return errors.New("cache: response is not JSON")
+	}
+	now := time.Now()
+	b, err := json.Marshal(cacheEntry{
+		URL:     url,
+		Fetched: now,
+		Expires: now.Add(ttlFor(url, body, now)),
+		Body:    body,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), c.path(url))
+}
+
+// expired reports whether e is stale at time now.
+func (c *Cache) expired(e *cacheEntry, now time.Time) bool {
+	if c.ttl > 0 {
+		return now.Sub(e.Fetched) > c.ttl
+	}
+	return now.After(e.Expires)
+}
+
+// Stats scans the cache directory. A missing directory is an empty cache.
+func (c *Cache) Stats() (CacheStats, error) {
+	st := CacheStats{Dir: c.dir}
+	now := time.Now()
+	err := c.each(func(path string, info os.FileInfo, e *cacheEntry) error {
+		st.Entries++
+		st.Bytes += info.Size()
+		if e == nil || c.expired(e, now) {
+			st.Expired++
+		}
+		if e != nil {
+			if st.Oldest.IsZero() || e.Fetched.Before(st.Oldest) {
+				st.Oldest = e.Fetched
+			}
+			if e.Fetched.After(st.Newest) {
+				st.Newest = e.Fetched
+			}
+		}
+		return nil
+	})
+	return st, err
+}
+
+// Prune removes expired and unreadable entries and returns how many were removed.
+func (c *Cache) Prune() (int, error) {
+	now := time.Now()
+	n := 0
+	err := c.each(func(path string, _ os.FileInfo, e *cacheEntry) error {
+		if e != nil && !c.expired(e, now) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// Clear removes every entry and returns how many were removed.
+func (c *Cache) Clear() (int, error) {
+	n := 0
+	err := c.each(func(path string, _ os.FileInfo, _ *cacheEntry) error {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// each calls fn for every entry file in the cache directory. e is nil if the
+// file could not be parsed.
+func (c *Cache) each(fn func(path string, info os.FileInfo, e *cacheEntry) error) error {
+	des, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, de := range des {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(c.dir, de.Name())
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		var e *cacheEntry
+		if b, err := os.ReadFile(path); err == nil {
+			var parsed cacheEntry
+			if json.Unmarshal(b, &parsed) == nil {
+				e = &parsed
+			}
+		}
+		if err := fn(path, info, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ttlFor chooses how long a response stays fresh. Story lists and search
+// results churn constantly; items settle down as they age and are frozen
+// once HN closes them to new comments after two weeks. Endpoints are told
+// apart by path so that the policy holds for mirrors and fakes too.
+func ttlFor(rawURL string, body []byte, now time.Time) time.Duration {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 5 * time.Minute
+	}
+	switch dir, base := path.Base(path.Dir(u.Path)), path.Base(u.Path); {
+	case dir == "item", dir == "items":
+		var v struct {
+			Time       int64 `json:"time"`
+			CreatedAtI int64 `json:"created_at_i"`
+		}
+		json.Unmarshal(body, &v) //nolint:errcheck
+		t := max(v.Time, v.CreatedAtI)
+		if t == 0 {
+			return 5 * time.Minute
+		}
+		switch age := now.Sub(time.Unix(t, 0)); {
+		case age > 14*24*time.Hour:
+			return 30 * 24 * time.Hour
+		case age > 24*time.Hour:
+			return time.Hour
+		default:
+			return 5 * time.Minute
+		}
+	case dir == "user":
+		return time.Hour
+	case strings.HasPrefix(base, "search"):
+		return 10 * time.Minute
+	default:
+		// Story lists (topstories.json etc.).
+		return 5 * time.Minute
+	}
+}
@@ -1,47 +1,136 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-const (
-	baseURL    = "https://hacker-news.firebaseio.com/v0"
-	algoliaURL = "https://hn.algolia.com/api/v1"
-)
-
 // Client is an HN Firebase API client.
 type Client struct {
-	http *http.Client
+	http       *http.Client
+	baseURL    string
+	algoliaURL string
+	userAgent  string
+	strategy   Strategy
+	cache      *Cache
+	offline    bool
+
+	staleMu    sync.Mutex
+	staleSince time.Time
+
+	retry    RetryPolicy
+	limiter  *limiter
+	logf     func(format string, args ...any)
+	requests atomic.Int64
+	retries  atomic.Int64
+	failures atomic.Int64
 }
 
-// New returns a new Client.
-func New() *Client {
-	return &Client{
-		http: &http.Client{Timeout: 10 * time.Second},
+// New returns a new Client talking to the public HN APIs, configured by opts.
+func New(opts ...Option) *Client {
+	c := &Client{
+		http:       &http.Client{Timeout: 10 * time.Second},
+		baseURL:    DefaultBaseURL,
+		algoliaURL: DefaultAlgoliaURL,
+		userAgent:  DefaultUserAgent,
+		strategy:   StrategyFirebase,
+		retry:      DefaultRetryPolicy,
+		limiter:    newLimiter(DefaultRateLimit, DefaultRateLimit),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
+}
+
+// freshKey marks a context made by Fresh.
+type freshKey struct{}
+
+// Fresh returns a copy of ctx under which requests skip the cache lookup,
+// as for a refresh the user asked for. Responses still refresh the cache,
+// and offline mode still reads it.
+func Fresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshKey{}, true)
+}
+
+// isFresh reports whether ctx was made by Fresh.
+func isFresh(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshKey{}).(bool)
+	return fresh
 }
 
-func (c *Client) get(url string, v any) error {
-	resp, err := c.http.Get(url)
+// get fetches url and decodes the JSON response into v, serving it from the
+// cache when a fresh entry exists, unless ctx was made by Fresh. In
+// offline mode only the cache is used.
+func (c *Client) get(ctx context.Context, url string, v any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if c.offline {
+		return c.getOffline(url, v)
+	}
+	if c.cache != nil && !isFresh(ctx) {
+		if body, ok := c.cache.Get(url); ok {
+			return json.Unmarshal(body, v)
+		}
+	}
+	body, err := c.fetch(ctx, url)
 	if err != nil {
 		return err
 	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return err
+	}
+	if c.cache != nil {
+		c.cache.Put(url, body) //nolint:errcheck // a failed write only costs a refetch
+	}
+	return nil
+}
+
+// fetchOnce makes a single rate-limited GET request for url.
+func (c *Client) fetchOnce(ctx context.Context, url string) ([]byte, error) {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	c.requests.Add(1)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HN API: %s", resp.Status)
+		return nil, &statusError{
+			status:     resp.Status,
+			code:       resp.StatusCode,
+			retryAfter: retryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
-	return json.NewDecoder(resp.Body).Decode(v)
+	return io.ReadAll(resp.Body)
 }
 
 // Item fetches a single item by ID.
 func (c *Client) Item(id int) (*Item, error) {
+	return c.ItemContext(context.Background(), id)
+}
+
+// ItemContext is like Item but aborts when ctx is done.
+func (c *Client) ItemContext(ctx context.Context, id int) (*Item, error) {
 	var item Item
-	if err := c.get(fmt.Sprintf("%s/item/%d.json", baseURL, id), &item); err != nil {
+	if err := c.get(ctx, fmt.Sprintf("%s/item/%d.json", c.baseURL, id), &item); err != nil {
 		return nil, err
 	}
 	return &item, nil
@@ -49,17 +138,22 @@ func (c *Client) Item(id int) (*Item, error) {
 
 // User fetches a user by username.
 func (c *Client) User(username string) (*User, error) {
+	return c.UserContext(context.Background(), username)
+}
+
+// UserContext is like User but aborts when ctx is done.
+func (c *Client) UserContext(ctx context.Context, username string) (*User, error) {
 	var user User
-	if err := c.get(fmt.Sprintf("%s/user/%s.json", baseURL, username), &user); err != nil {
+	if err := c.get(ctx, fmt.Sprintf("%s/user/%s.json", c.baseURL, username), &user); err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-// list fetches a named list of item IDs (e.g. topstories, newstories).
-func (c *Client) list(name string) ([]int, error) {
+// StoryIDs fetches a named list of item IDs (e.g. topstories, newstories).
+func (c *Client) StoryIDs(ctx context.Context, name string) ([]int, error) {
 	var ids []int
-	if err := c.get(fmt.Sprintf("%s/%s.json", baseURL, name), &ids); err != nil {
+	if err := c.get(ctx, fmt.Sprintf("%s/%s.json", c.baseURL, name), &ids); err != nil {
 		return nil, err
 	}
 	return ids, nil
@@ -67,17 +161,69 @@ func (c *Client) list(name string) ([]int, error) {
 
 // Stories fetches the top N items from a named list, in parallel.
 func (c *Client) Stories(listName string, n int) ([]*Item, error) {
-	ids, err := c.list(listName)
+	return c.StoriesContext(context.Background(), listName, n)
+}
+
+// StoriesContext is like Stories but stops fetching when ctx is done.
+func (c *Client) StoriesContext(ctx context.Context, listName string, n int) ([]*Item, error) {
+	return c.StoriesPageContext(ctx, listName, 0, n)
+}
+
+// StoriesPage fetches N items from a named list, skipping the first offset.
+func (c *Client) StoriesPage(listName string, offset, n int) ([]*Item, error) {
+	return c.StoriesPageContext(context.Background(), listName, offset, n)
+}
+
+// StoriesPageContext is like StoriesPage but stops fetching when ctx is done.
+func (c *Client) StoriesPageContext(ctx context.Context, listName string, offset, n int) ([]*Item, error) {
+	ids, err := c.StoryIDs(ctx, listName)
 	if err != nil {
 		return nil, err
 	}
-	if n > len(ids) {
-		n = len(ids)
+	offset = min(offset, len(ids))
+	return c.ItemsContext(ctx, ids[offset:min(offset+n, len(ids))])
+}
+
+// Items fetches the items with the given IDs in parallel, in order.
+func (c *Client) Items(ids []int) ([]*Item, error) {
+	return c.ItemsContext(context.Background(), ids)
+}
+
+// ItemsContext is like Items but stops fetching when ctx is done. Items that
+// fail to load are left out; the first such error is returned along with
+// the rest.
+func (c *Client) ItemsContext(ctx context.Context, ids []int) ([]*Item, error) {
+	return c.items(ctx, ids, c.ItemContext)
+}
+
+// items fetches ids in parallel with fetch, as ItemsContext describes.
+func (c *Client) items(ctx context.Context, ids []int, fetch func(context.Context, int) (*Item, error)) ([]*Item, error) {
+	items, errs, err := c.fetchEach(ctx, ids, fetch)
+	if err != nil {
+		return nil, err
 	}
-	ids = ids[:n]
 
-	items := make([]*Item, n)
-	errs := make([]error, n)
+	// Filter errors: return first non-nil error but still return partial results.
+	// Items missing from an offline cache are skipped rather than reported.
+	var firstErr error
+	result := make([]*Item, 0, len(ids))
+	for i, item := range items {
+		if errs[i] != nil && firstErr == nil && !errors.Is(errs[i], ErrNotCached) {
+			firstErr = errs[i]
+		}
+		if item != nil {
+			result = append(result, item)
+		}
+	}
+	return result, firstErr
+}
+
+// fetchEach fetches ids in parallel with fetch, returning the item and
+// error for each ID at its index. err is set only if ctx is done.
+func (c *Client) fetchEach(ctx context.Context, ids []int, fetch func(context.Context, int) (*Item, error)) (items []*Item, errs []error, err error) {
+	n := len(ids)
+	items = make([]*Item, n)
+	errs = make([]error, n)
 	var wg sync.WaitGroup
 	// Limit concurrency to avoid overwhelming the API.
 	sem := make(chan struct{}, 20)
@@ -85,27 +231,21 @@ func (c *Client) Stories(listName string, n int) ([]*Item, error) {
 		wg.Add(1)
 		go func(i, id int) {
 			defer wg.Done()
-			sem <- struct{}{}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
 			defer func() { <-sem }()
-			item, err := c.Item(id)
-			items[i] = item
-			errs[i] = err
+			items[i], errs[i] = fetch(ctx, id)
 		}(i, id)
 	}
 	wg.Wait()
-
-	// Filter errors: return first non-nil error but still return partial results.
-	var firstErr error
-	result := make([]*Item, 0, n)
-	for i, item := range items {
-		if errs[i] != nil && firstErr == nil {
-			firstErr = errs[i]
-		}
-		if item != nil {
-			result = append(result, item)
-		}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
 	}
-	return result, firstErr
+	return items, errs, nil
 }
 
 // TopStories returns the top N front-page stories.
@@ -125,44 +265,3 @@ func (c *Client) ShowStories(n int) ([]*Item, error) { return c.Stories("showsto
 
 // JobStories returns the N latest job stories.
 func (c *Client) JobStories(n int) ([]*Item, error) { return c.Stories("jobstories", n) }
-
-// algoliaHit is a single result from the Algolia HN search API.
-type algoliaHit struct {
-	ObjectID    string `json:"objectID"`
-	Title       string `json:"title"`
-	URL         string `json:"url"`
-	Author      string `json:"author"`
-	Points      int    `json:"points"`
-	NumComments int    `json:"num_comments"`
-	CreatedAtI  int64  `json:"created_at_i"`
-}
-
-type algoliaResponse struct {
-	Hits []algoliaHit `json:"hits"`
-}
-
-// Search queries the Algolia HN search API and returns matching stories as Items.
-func (c *Client) Search(query string, n int) ([]*Item, error) {
-	u := fmt.Sprintf("%s/search?query=%s&hitsPerPage=%d&tags=story",
-		algoliaURL, url.QueryEscape(query), n)
-	var resp algoliaResponse
-	if err := c.get(u, &resp); err != nil {
-		return nil, err
-	}
-	items := make([]*Item, 0, len(resp.Hits))
-	for _, h := range resp.Hits {
-		id := 0
-		fmt.Sscanf(h.ObjectID, "%d", &id)
-		items = append(items, &Item{
-			ID:          id,
-			Type:        "story",
-			Title:       h.Title,
-			URL:         h.URL,
-			By:          h.Author,
-			Score:       h.Points,
-			Descendants: h.NumComments,
-			Time:        h.CreatedAtI,
-		})
-	}
-	return items, nil
-}
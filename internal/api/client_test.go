@@ -0,0 +1,189 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/api/apitest"
+)
+
+func TestStories(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(
+		&api.Item{ID: 1, Type: "story", Title: "one", Score: 10},
+		&api.Item{ID: 2, Type: "story", Title: "two", Score: 20},
+		&api.Item{ID: 3, Type: "story", Title: "three", Score: 30},
+	)
+	srv.SetList("topstories", 3, 1, 2)
+
+	items, err := srv.Client().TopStories(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, item := range items {
+		got = append(got, item.ID)
+	}
+	if len(got) != 2 || got[0] != 3 || got[1] != 1 {
+		t.Errorf("TopStories(2) = %v, want [3 1] in list order", got)
+	}
+}
+
+func TestStoriesPage(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	for id := 1; id <= 5; id++ {
+		srv.AddItems(&api.Item{ID: id, Type: "story"})
+	}
+	srv.SetList("newstories", 5, 4, 3, 2, 1)
+	c := srv.Client()
+
+	tests := []struct {
+		offset, n int
+		want      []int
+	}{
+		{0, 2, []int{5, 4}},
+		{2, 2, []int{3, 2}},
+		{4, 2, []int{1}},
+		{9, 2, nil},
+	}
+	for _, tt := range tests {
+		items, err := c.StoriesPage("newstories", tt.offset, tt.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(items); !slices.Equal(got, tt.want) {
+			t.Errorf("StoriesPage(%d, %d) = %v, want %v", tt.offset, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestStoriesMissingList(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+
+	if _, err := srv.Client().NewStories(10); err == nil {
+		t.Fatal("NewStories succeeded for a list the server does not have")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(
+		&api.Item{ID: 1, Type: "story", Title: "Go 1.24 released", By: "gopher", Score: 500, Descendants: 120, Time: 1700000000},
+		&api.Item{ID: 2, Type: "story", Title: "Rust 2024 edition"},
+		&api.Item{ID: 3, Type: "comment", Text: "go is great"},
+		&api.Item{ID: 4, Type: "story", Title: "Why I left Go"},
+	)
+
+	items, err := srv.Client().Search("go", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Search returned %d items, want 2", len(items))
+	}
+	want := api.Item{ID: 1, Type: "story", Title: "Go 1.24 released", By: "gopher", Score: 500, Descendants: 120, Time: 1700000000}
+	if got := *items[0]; got.ID != want.ID || got.Title != want.Title || got.By != want.By ||
+		got.Score != want.Score || got.Descendants != want.Descendants || got.Time != want.Time || got.Type != want.Type {
+		t.Errorf("Search hit = %+v, want %+v", got, want)
+	}
+	if items[1].ID != 4 {
+		t.Errorf("second hit ID = %d, want 4", items[1].ID)
+	}
+}
+
+func TestItem(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(&api.Item{ID: 42, Type: "story", Title: "answer", By: "dent", Kids: []int{43, 44}})
+
+	item, err := srv.Client().Item(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Title != "answer" || item.By != "dent" || len(item.Kids) != 2 {
+		t.Errorf("Item(42) = %+v", item)
+	}
+
+	// Firebase answers null for unknown items.
+	item, err = srv.Client().Item(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.ID != 0 {
+		t.Errorf("Item(7) = %+v, want zero item", item)
+	}
+}
+
+func TestItemRetries(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(&api.Item{ID: 1, Type: "story", Title: "flaky"})
+	srv.FailNext("/v0/item/1.json", http.StatusServiceUnavailable, http.StatusTooManyRequests)
+
+	item, err := srv.Client().Item(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Title != "flaky" {
+		t.Errorf("Item(1).Title = %q, want %q", item.Title, "flaky")
+	}
+	if n := srv.Requests("/v0/item/1.json"); n != 3 {
+		t.Errorf("server saw %d requests, want 3", n)
+	}
+}
+
+func TestItemNoRetryOnClientError(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.FailNext("/v0/item/1.json", http.StatusForbidden)
+
+	if _, err := srv.Client().Item(1); err == nil {
+		t.Fatal("Item succeeded despite a 403")
+	}
+	if n := srv.Requests("/v0/item/1.json"); n != 1 {
+		t.Errorf("server saw %d requests, want 1", n)
+	}
+}
+
+func TestItemContextCancelled(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(&api.Item{ID: 1, Type: "story"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := srv.Client().ItemContext(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("ItemContext with cancelled context: err = %v, want context.Canceled", err)
+	}
+}
+
+func TestFreshSkipsCache(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.SetList("topstories", 1, 2)
+	srv.AddItems(&api.Item{ID: 1, Type: "story"}, &api.Item{ID: 2, Type: "story"}, &api.Item{ID: 3, Type: "story"})
+	c := srv.Client(api.WithCache(api.NewCache(t.TempDir(), 0)))
+	ctx := context.Background()
+
+	if _, err := c.StoryIDs(ctx, "topstories"); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetList("topstories", 3, 1)
+	if ids, _ := c.StoryIDs(ctx, "topstories"); !slices.Equal(ids, []int{1, 2}) {
+		t.Fatalf("cached StoryIDs = %v, want [1 2]", ids)
+	}
+	if ids, _ := c.StoryIDs(api.Fresh(ctx), "topstories"); !slices.Equal(ids, []int{3, 1}) {
+		t.Fatalf("StoryIDs with Fresh = %v, want the new list [3 1]", ids)
+	}
+	if ids, _ := c.StoryIDs(ctx, "topstories"); !slices.Equal(ids, []int{3, 1}) {
+		t.Errorf("StoryIDs after Fresh = %v, want the cache updated to [3 1]", ids)
+	}
+}
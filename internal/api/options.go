@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Default API endpoints.
+const (
+	DefaultBaseURL    = "https://hacker-news.firebaseio.com/v0"
+	DefaultAlgoliaURL = "https://hn.algolia.com/api/v1"
+)
+
+// DefaultUserAgent is sent with every request unless WithUserAgent overrides it.
+const DefaultUserAgent = "hncli"
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL points the client at a Firebase-compatible HN API, e.g. a
+// mirror or a local fake. The URL should include the version path (".../v0").
+func WithBaseURL(u string) Option {
+	return func(c *Client) { c.baseURL = strings.TrimSuffix(u, "/") }
+}
+
+// WithAlgoliaURL points the client at an Algolia-compatible HN search API.
+// The URL should include the version path (".../api/v1").
+func WithAlgoliaURL(u string) Option {
+	return func(c *Client) { c.algoliaURL = strings.TrimSuffix(u, "/") }
+}
+
+// WithHTTPClient makes the client send requests through hc, e.g. one with a
+// custom transport or timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// WithStrategy sets the backend Thread tries first. The default is StrategyFirebase.
+func WithStrategy(s Strategy) Option {
+	return func(c *Client) { c.strategy = s }
+}
+
+// WithCache makes the client read from and write to cache. A nil cache
+// disables caching, which is the default.
+func WithCache(cache *Cache) Option {
+	return func(c *Client) { c.cache = cache }
+}
+
+// WithOffline makes the client answer every request from the cache, however
+// old the entry, and never touch the network. It requires WithCache.
+func WithOffline(offline bool) Option {
+	return func(c *Client) { c.offline = offline }
+}
+
+// WithRetry sets the client's retry policy. The default is DefaultRetryPolicy.
+func WithRetry(p RetryPolicy) Option {
+	return func(c *Client) { c.retry = p }
+}
+
+// WithRateLimit limits the client to rps requests per second across all
+// goroutines, allowing bursts of up to burst requests. rps <= 0 removes the
+// limit. The default is DefaultRateLimit.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		if rps <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newLimiter(rps, burst)
+	}
+}
+
+// WithLogf sets a printf-style function used to log retries and failures.
+func WithLogf(logf func(format string, args ...any)) Option {
+	return func(c *Client) { c.logf = logf }
+}
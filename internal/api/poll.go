@@ -0,0 +1,49 @@
+package api
+
+import "context"
+
+// PollOptions fetches the options of a poll, in the order the poll lists
+// them. Each option's Text is its label and Score its votes.
+func (c *Client) PollOptions(poll *Item) ([]*Item, error) {
+	return c.PollOptionsContext(context.Background(), poll)
+}
+
+// PollOptionsContext is like PollOptions but aborts when ctx is done.
+// Options that fail to load are left out and the first error is returned
+// with the rest.
+func (c *Client) PollOptionsContext(ctx context.Context, poll *Item) ([]*Item, error) {
+	return c.ItemsContext(ctx, poll.Parts)
+}
+
+// Votes returns the total score of a poll's options.
+func Votes(options []*Item) int {
+	total := 0
+	for _, o := range options {
+		total += o.Score
+	}
+	return total
+}
+
+// addPollOptions fills in t.Options if its story is a poll. Algolia
+// doesn't list a poll's options, so a thread loaded from there has the
+// poll fetched again from Firebase for them. A thread whose options can't
+// be fetched is still shown, without them.
+func (c *Client) addPollOptions(ctx context.Context, t *Thread) {
+	if t.Story.Type != "poll" {
+		return
+	}
+	poll := t.Story
+	if len(poll.Parts) == 0 {
+		p, err := c.ItemContext(ctx, poll.ID)
+		if err != nil {
+			c.log("poll %d: %v", poll.ID, err)
+			return
+		}
+		poll.Parts = p.Parts
+	}
+	options, err := c.PollOptionsContext(ctx, poll)
+	if err != nil {
+		c.log("poll %d options: %v", poll.ID, err)
+	}
+	t.Options = options
+}
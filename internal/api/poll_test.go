@@ -0,0 +1,46 @@
+package api_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/api/apitest"
+)
+
+func TestPollOptions(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(
+		&api.Item{ID: 1, Type: "poll", Title: "Tabs or spaces?", Parts: []int{3, 2}, Kids: []int{4}},
+		&api.Item{ID: 2, Type: "pollopt", Poll: 1, Text: "Spaces", Score: 30},
+		&api.Item{ID: 3, Type: "pollopt", Poll: 1, Text: "Tabs", Score: 10},
+		&api.Item{ID: 4, Type: "comment", Parent: 1, By: "a", Text: "neither"},
+	)
+
+	options, err := srv.Client().PollOptions(&api.Item{ID: 1, Parts: []int{3, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(options); !slices.Equal(got, []int{3, 2}) {
+		t.Errorf("PollOptions = %v, want [3 2] in poll order", got)
+	}
+	if got := api.Votes(options); got != 40 {
+		t.Errorf("Votes = %d, want 40", got)
+	}
+
+	for _, s := range []api.Strategy{api.StrategyFirebase, api.StrategyAlgolia} {
+		t.Run(string(s), func(t *testing.T) {
+			th, err := srv.Client(api.WithStrategy(s)).Thread(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(th.Options); !slices.Equal(got, []int{3, 2}) {
+				t.Errorf("thread options = %v, want [3 2]", got)
+			}
+			if len(th.Comments) != 1 {
+				t.Errorf("thread has %d comments, want 1", len(th.Comments))
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket shared by every request a Client makes, so that
+// concurrent loaders (story lists, comment trees, user submissions) together
+// stay under a single request rate.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64 // bucket capacity
+	tokens float64 // may go negative: callers wait off their debt
+	last   time.Time
+}
+
+// newLimiter returns a limiter allowing rate requests per second with bursts
+// of up to burst requests. The bucket starts full.
+func newLimiter(rate float64, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until a token is available or ctx is done. Tokens are handed
+// out in call order: each caller reserves one immediately and sleeps until
+// the bucket has refilled enough to cover it.
+func (l *limiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back so later callers aren't delayed by it.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
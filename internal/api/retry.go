@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. Network errors,
+// 5xx responses and 429 Too Many Requests are retried with exponential
+// backoff and jitter; other failures are returned immediately.
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt; 0 disables retrying
+	BaseDelay  time.Duration // delay before the first retry, doubled for each one after
+	MaxDelay   time.Duration // upper bound on any single delay
+}
+
+// DefaultRetryPolicy is used by clients returned from New.
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3, BaseDelay: 250 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// DefaultRateLimit is the default number of requests per second a Client makes.
+const DefaultRateLimit = 30
+
+// RequestStats counts the HTTP requests a Client has made.
+type RequestStats struct {
+	Requests int64 // attempts sent over the network, including retries
+	Retries  int64
+	Failures int64 // requests that failed after exhausting their retries
+}
+
+// Stats returns counts of the requests made so far.
+func (c *Client) Stats() RequestStats {
+	return RequestStats{
+		Requests: c.requests.Load(),
+		Retries:  c.retries.Load(),
+		Failures: c.failures.Load(),
+	}
+}
+
+// statusError is a non-200 response from the API.
+type statusError struct {
+	status     string
+	code       int
+	retryAfter time.Duration // from a Retry-After header, if any
+}
+
+func (e *statusError) Error() string { return "HN API: " + e.status }
+
+// retryable reports whether err is worth retrying.
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusTooManyRequests || se.code >= 500
+	}
+	// Anything else that reached us is a transport error; cancellation is
+	// checked by the caller before retrying.
+	return true
+}
+
+// backoff returns how long to wait before retry number attempt (1-based):
+// the base delay doubled per attempt, capped, with the upper half jittered
+// so that concurrent loaders don't retry in lockstep.
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	var se *statusError
+	if errors.As(err, &se) && se.retryAfter > 0 {
+		return min(se.retryAfter, p.MaxDelay)
+	}
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// fetch performs a GET of url with rate limiting and retries and returns
+// the response body.
+func (c *Client) fetch(ctx context.Context, url string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := c.fetchOnce(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt >= c.retry.MaxRetries || !retryable(err) {
+			c.failures.Add(1)
+			if attempt > 0 {
+				err = fmt.Errorf("%w (after %d retries)", err, attempt)
+			}
+			c.log("GET %s failed: %v", url, err)
+			return nil, err
+		}
+		delay := c.retry.backoff(attempt+1, err)
+		c.retries.Add(1)
+		c.log("GET %s: %v; retry %d/%d in %s", url, err, attempt+1, c.retry.MaxRetries, delay.Round(time.Millisecond))
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds.
+func retryAfter(h string) time.Duration {
+	if n, err := strconv.Atoi(h); err == nil && n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return 0
+}
+
+// log reports a message through the client's logger, if one is set.
+func (c *Client) log(format string, args ...any) {
+	if c.logf != nil {
+		c.logf(format, args...)
+	}
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearchTypes are the item kinds a search can be restricted to.
+var SearchTypes = []string{"story", "comment", "ask_hn", "show_hn", "poll", "job"}
+
+// SearchOptions describes an Algolia search. The zero value (plus a query)
+// searches stories by relevance.
+type SearchOptions struct {
+	Query       string
+	ByDate      bool      // newest first instead of by relevance
+	Author      string    // only items by this user
+	Since       time.Time // only items created at or after this time
+	Until       time.Time // only items created before this time
+	MinPoints   int
+	MinComments int
+	Type        string // one of SearchTypes; "" means story
+	Domain      string // only items linking to this host or its subdomains
+	Page        int    // zero-based
+	HitsPerPage int
+}
+
+// SearchResult is one page of search hits.
+type SearchResult struct {
+	Items []*Item
+	Page  int // zero-based page this result holds
+	Pages int // total pages available
+	Hits  int // total matching items
+}
+
+// algoliaHit is a single result from the Algolia HN search API. Comment
+// hits carry the story they belong to.
+type algoliaHit struct {
+	ObjectID    string   `json:"objectID"`
+	Tags        []string `json:"_tags"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	Author      string   `json:"author"`
+	Points      int      `json:"points"`
+	NumComments int      `json:"num_comments"`
+	CreatedAtI  int64    `json:"created_at_i"`
+	StoryText   string   `json:"story_text"`
+	CommentText string   `json:"comment_text"`
+	ParentID    int      `json:"parent_id"`
+	StoryID     int      `json:"story_id"`
+	StoryTitle  string   `json:"story_title"`
+	StoryURL    string   `json:"story_url"`
+}
+
+type algoliaResponse struct {
+	Hits    []algoliaHit `json:"hits"`
+	Page    int          `json:"page"`
+	NbPages int          `json:"nbPages"`
+	NbHits  int          `json:"nbHits"`
+}
+
+// Search queries the Algolia HN search API and returns matching stories as Items.
+func (c *Client) Search(query string, n int) ([]*Item, error) {
+	return c.SearchContext(context.Background(), query, n)
+}
+
+// SearchContext is like Search but aborts when ctx is done.
+func (c *Client) SearchContext(ctx context.Context, query string, n int) ([]*Item, error) {
+	res, err := c.SearchPage(ctx, SearchOptions{Query: query, HitsPerPage: n})
+	if err != nil {
+		return nil, err
+	}
+	return res.Items, nil
+}
+
+// SearchPage fetches a single page of results for opts.
+//
+// Algolia cannot filter by domain, so with opts.Domain set the query is
+// restricted to URLs (when it is otherwise empty) and hits on other hosts
+// are dropped afterwards; pages may then hold fewer than HitsPerPage items.
+func (c *Client) SearchPage(ctx context.Context, opts SearchOptions) (*SearchResult, error) {
+	u, err := c.searchURL(opts)
+	if err != nil {
+		return nil, err
+	}
+	var resp algoliaResponse
+	if err := c.get(ctx, u, &resp); err != nil {
+		return nil, err
+	}
+	res := &SearchResult{Page: resp.Page, Pages: resp.NbPages, Hits: resp.NbHits}
+	for _, h := range resp.Hits {
+		item := h.item()
+		if opts.Domain != "" && !item.OnDomain(opts.Domain) {
+			continue
+		}
+		res.Items = append(res.Items, item)
+	}
+	return res, nil
+}
+
+// SearchAll fetches every page of results for opts, starting at opts.Page.
+// Algolia stops paginating after 1000 hits.
+func (c *Client) SearchAll(ctx context.Context, opts SearchOptions) ([]*Item, error) {
+	var items []*Item
+	for {
+		res, err := c.SearchPage(ctx, opts)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, res.Items...)
+		opts.Page = res.Page + 1
+		if opts.Page >= res.Pages {
+			return items, nil
+		}
+	}
+}
+
+// searchURL builds the request URL for opts.
+func (c *Client) searchURL(opts SearchOptions) (string, error) {
+	typ := opts.Type
+	if typ == "" {
+		typ = "story"
+	}
+	if !slices.Contains(SearchTypes, typ) {
+		return "", fmt.Errorf("unknown search type %q (want one of %s)", typ, strings.Join(SearchTypes, ", "))
+	}
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && !opts.Since.Before(opts.Until) {
+		return "", fmt.Errorf("search: since (%s) is not before until (%s)",
+			opts.Since.Format(time.DateTime), opts.Until.Format(time.DateTime))
+	}
+
+	q := url.Values{}
+	q.Set("query", opts.Query)
+	tags := []string{typ}
+	if opts.Author != "" {
+		tags = append(tags, "author_"+opts.Author)
+	}
+	q.Set("tags", strings.Join(tags, ","))
+
+	var numeric []string
+	if !opts.Since.IsZero() {
+		numeric = append(numeric, fmt.Sprintf("created_at_i>=%d", opts.Since.Unix()))
+	}
+	if !opts.Until.IsZero() {
+		numeric = append(numeric, fmt.Sprintf("created_at_i<%d", opts.Until.Unix()))
+	}
+	if opts.MinPoints > 0 {
+		numeric = append(numeric, fmt.Sprintf("points>=%d", opts.MinPoints))
+	}
+	if opts.MinComments > 0 {
+		numeric = append(numeric, fmt.Sprintf("num_comments>=%d", opts.MinComments))
+	}
+	if len(numeric) > 0 {
+		q.Set("numericFilters", strings.Join(numeric, ","))
+	}
+
+	if opts.Domain != "" && opts.Query == "" {
+		q.Set("query", opts.Domain)
+		q.Set("restrictSearchableAttributes", "url")
+	}
+	if opts.HitsPerPage > 0 {
+		q.Set("hitsPerPage", strconv.Itoa(opts.HitsPerPage))
+	}
+	if opts.Page > 0 {
+		q.Set("page", strconv.Itoa(opts.Page))
+	}
+
+	endpoint := "search"
+	if opts.ByDate {
+		endpoint = "search_by_date"
+	}
+	return fmt.Sprintf("%s/%s?%s", c.algoliaURL, endpoint, q.Encode()), nil
+}
+
+// item converts the hit to an Item. The type comes from the hit's tags,
+// since Algolia has no type field in search results.
+func (h algoliaHit) item() *Item {
+	id, _ := strconv.Atoi(h.ObjectID)
+	item := &Item{
+		ID:          id,
+		Type:        "story",
+		Title:       h.Title,
+		URL:         h.URL,
+		By:          h.Author,
+		Score:       h.Points,
+		Descendants: h.NumComments,
+		Time:        h.CreatedAtI,
+		Text:        h.StoryText,
+	}
+	for _, t := range []string{"comment", "poll", "pollopt", "job"} {
+		if slices.Contains(h.Tags, t) {
+			item.Type = t
+			break
+		}
+	}
+	if item.Type == "comment" {
+		item.Text = h.CommentText
+		item.Parent = h.ParentID
+		item.StoryID = h.StoryID
+		item.StoryTitle = h.StoryTitle
+		item.StoryURL = h.StoryURL
+	}
+	return item
+}
+
+// OnDomain reports whether the item (or, for a comment, its story, if
+// known) links to domain or one of its subdomains.
+func (i Item) OnDomain(domain string) bool {
+	raw := i.URL
+	if i.Type == "comment" {
+		raw = i.StoryURL
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	domain = strings.ToLower(strings.TrimPrefix(domain, "www."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
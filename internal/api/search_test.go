@@ -0,0 +1,116 @@
+package api_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/api/apitest"
+)
+
+// searchServer returns a server holding a small mix of stories and comments.
+func searchServer() *apitest.Server {
+	srv := apitest.NewServer()
+	srv.AddItems(
+		&api.Item{ID: 1, Type: "story", Title: "Go generics in depth", URL: "https://go.dev/blog/generics", By: "rsc", Score: 300, Descendants: 80, Time: 1700000000},
+		&api.Item{ID: 2, Type: "story", Title: "Show HN: A Go TUI", URL: "https://github.com/x/tui", By: "gopher", Score: 40, Descendants: 5, Time: 1700100000},
+		&api.Item{ID: 3, Type: "story", Title: "Ask HN: Learning Go?", By: "newbie", Score: 12, Descendants: 30, Time: 1700200000},
+		&api.Item{ID: 4, Type: "comment", Text: "Go generics took a while", By: "gopher", Parent: 1, Time: 1700000500},
+		&api.Item{ID: 5, Type: "comment", Text: "nested go reply", By: "rsc", Parent: 4, Time: 1700000600},
+		&api.Item{ID: 6, Type: "story", Title: "Go on the blog", URL: "https://blog.go.dev/post", By: "rsc", Score: 90, Time: 1700300000},
+	)
+	return srv
+}
+
+func ids(items []*api.Item) []int {
+	var out []int
+	for _, item := range items {
+		out = append(out, item.ID)
+	}
+	return out
+}
+
+func TestSearchPageFilters(t *testing.T) {
+	srv := searchServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	tests := []struct {
+		name string
+		opts api.SearchOptions
+		want []int
+	}{
+		{"relevance", api.SearchOptions{Query: "go"}, []int{1, 2, 3, 6}},
+		{"by date", api.SearchOptions{Query: "go", ByDate: true}, []int{6, 3, 2, 1}},
+		{"author", api.SearchOptions{Query: "go", Author: "rsc"}, []int{1, 6}},
+		{"since", api.SearchOptions{Query: "go", Since: time.Unix(1700100000, 0)}, []int{2, 3, 6}},
+		{"until", api.SearchOptions{Query: "go", Until: time.Unix(1700100000, 0)}, []int{1}},
+		{"min points", api.SearchOptions{Query: "go", MinPoints: 50}, []int{1, 6}},
+		{"min comments", api.SearchOptions{Query: "go", MinComments: 10}, []int{1, 3}},
+		{"show hn", api.SearchOptions{Query: "go", Type: "show_hn"}, []int{2}},
+		{"ask hn", api.SearchOptions{Query: "go", Type: "ask_hn"}, []int{3}},
+		{"comments", api.SearchOptions{Query: "go", Type: "comment"}, []int{4, 5}},
+		{"domain with query", api.SearchOptions{Query: "go", Domain: "go.dev"}, []int{1, 6}},
+		{"domain only", api.SearchOptions{Domain: "github.com"}, []int{2}},
+		{"page", api.SearchOptions{Query: "go", HitsPerPage: 3, Page: 1}, []int{6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := c.SearchPage(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(res.Items); !slices.Equal(got, tt.want) {
+				t.Errorf("got IDs %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchCommentContext(t *testing.T) {
+	srv := searchServer()
+	defer srv.Close()
+
+	res, err := srv.Client().SearchPage(context.Background(), api.SearchOptions{Query: "nested", Type: "comment"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Items) != 1 {
+		t.Fatalf("got %d hits, want 1", len(res.Items))
+	}
+	got := res.Items[0]
+	if got.Type != "comment" || got.Text != "nested go reply" || got.Parent != 4 ||
+		got.StoryID != 1 || got.StoryTitle != "Go generics in depth" || got.StoryURL != "https://go.dev/blog/generics" {
+		t.Errorf("comment hit = %+v", got)
+	}
+}
+
+func TestSearchAll(t *testing.T) {
+	srv := searchServer()
+	defer srv.Close()
+
+	items, err := srv.Client().SearchAll(context.Background(), api.SearchOptions{Query: "go", HitsPerPage: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(items), []int{1, 2, 3, 6}; !slices.Equal(got, want) {
+		t.Errorf("got IDs %v, want %v", got, want)
+	}
+	if n := srv.Requests("/api/v1/search"); n != 4 {
+		t.Errorf("made %d requests, want 4", n)
+	}
+}
+
+func TestSearchInvalidOptions(t *testing.T) {
+	c := api.New()
+	for _, opts := range []api.SearchOptions{
+		{Query: "go", Type: "pollopt"},
+		{Query: "go", Since: time.Unix(2, 0), Until: time.Unix(1, 0)},
+	} {
+		if _, err := c.SearchPage(context.Background(), opts); err == nil {
+			t.Errorf("SearchPage(%+v) succeeded, want error", opts)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Event is a change pushed over a Firebase event stream. Path is where the
+// change happened, relative to the watched path ("/" for all of it), and
+// Data the JSON value there. A put replaces the value at Path; a patch
+// merges Data's keys into it.
+type Event struct {
+	Type string          `json:"type"`
+	Path string          `json:"path"`
+	Data json.RawMessage `json:"data"`
+}
+
+// minReconnect is the least time Watch waits before reconnecting, however
+// the retry policy is set.
+const minReconnect = 100 * time.Millisecond
+
+// errStreamClosed reports that the server ended an event stream.
+var errStreamClosed = errors.New("event stream closed by server")
+
+// Watch streams changes to path. See WatchContext.
+func (c *Client) Watch(path string) <-chan Event {
+	return c.WatchContext(context.Background(), path)
+}
+
+// WatchContext streams changes to path, relative to the API root, e.g.
+// "maxitem" or "item/8863", using Firebase's server-sent events. The first
+// event on each connection is a put of the whole current value. A dropped
+// stream is reconnected with the backoff of the client's retry policy, so
+// consumers should treat that first put as the state to start over from.
+// The channel is closed once ctx is done, or straight away in offline mode.
+func (c *Client) WatchContext(ctx context.Context, path string) <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		if c.offline {
+			return
+		}
+		url := fmt.Sprintf("%s/%s.json", c.baseURL, path)
+		for attempt := 1; ; attempt++ {
+			got, err := c.stream(ctx, url, ch)
+			if ctx.Err() != nil {
+				return
+			}
+			if got {
+				attempt = 1
+			}
+			delay := max(minReconnect, c.retry.backoff(attempt, err))
+			c.log("watch %s: %v; reconnecting in %s", url, err, delay.Round(time.Millisecond))
+			t := time.NewTimer(delay)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// stream sends the put and patch events from a single connection to url
+// to ch until the connection fails, and reports whether any were sent.
+func (c *Client) stream(ctx context.Context, url string, ch chan<- Event) (bool, error) {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return false, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("User-Agent", c.userAgent)
+	c.requests.Add(1)
+	hc := *c.http
+	hc.Timeout = 0 // the stream stays open for as long as it is watched
+	resp, err := hc.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, &statusError{
+			status:     resp.Status,
+			code:       resp.StatusCode,
+			retryAfter: retryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	r := bufio.NewReader(resp.Body)
+	got := false
+	var event string
+	var data strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			return got, errStreamClosed
+		}
+		if err != nil {
+			return got, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			if strings.HasPrefix(line, ":") {
+				continue // comment
+			}
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(value)
+			}
+			continue
+		}
+
+		// A blank line ends the event. keep-alive and unknown events are
+		// ignored.
+		switch event {
+		case "put", "patch":
+			var e Event
+			if err := json.Unmarshal([]byte(data.String()), &e); err != nil {
+				return got, fmt.Errorf("bad %s event: %w", event, err)
+			}
+			e.Type = event
+			select {
+			case ch <- e:
+				got = true
+			case <-ctx.Done():
+				return got, ctx.Err()
+			}
+		case "cancel", "auth_revoked":
+			return got, fmt.Errorf("event stream ended by server: %s", event)
+		}
+		event = ""
+		data.Reset()
+	}
+}
+
+// WatchUpdates streams the recently changed items and profiles. See
+// WatchUpdatesContext.
+func (c *Client) WatchUpdates() <-chan *Updates {
+	return c.WatchUpdatesContext(context.Background())
+}
+
+// WatchUpdatesContext streams what the updates endpoint reports each time
+// it changes, starting with its current value. The channel is closed once
+// ctx is done.
+func (c *Client) WatchUpdatesContext(ctx context.Context) <-chan *Updates {
+	ch := make(chan *Updates)
+	go func() {
+		defer close(ch)
+		var u Updates
+		for e := range c.WatchContext(ctx, "updates") {
+			var err error
+			switch {
+			case e.Path == "/" && e.Type == "put":
+				u = Updates{}
+				err = json.Unmarshal(e.Data, &u)
+			case e.Path == "/": // a patch of some of the lists
+				err = json.Unmarshal(e.Data, &u)
+			case e.Path == "/items" && e.Type == "put":
+				err = json.Unmarshal(e.Data, &u.Items)
+			case e.Path == "/profiles" && e.Type == "put":
+				err = json.Unmarshal(e.Data, &u.Profiles)
+			default:
+				// A change deeper down; fetching the whole value is simpler
+				// than applying it.
+				var fresh *Updates
+				if fresh, err = c.UpdatesContext(ctx); err == nil {
+					u = *fresh
+				}
+			}
+			if err != nil {
+				c.log("watch updates: %v", err)
+				continue
+			}
+			snapshot := Updates{Items: append([]int(nil), u.Items...), Profiles: append([]string(nil), u.Profiles...)}
+			select {
+			case ch <- &snapshot:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// WatchMaxItem streams the largest item ID. See WatchMaxItemContext.
+func (c *Client) WatchMaxItem() <-chan int {
+	return c.WatchMaxItemContext(context.Background())
+}
+
+// WatchMaxItemContext streams the largest item ID each time it changes,
+// starting with its current value. The channel is closed once ctx is done.
+func (c *Client) WatchMaxItemContext(ctx context.Context) <-chan int {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for e := range c.WatchContext(ctx, "maxitem") {
+			var id int
+			if e.Path != "/" || json.Unmarshal(e.Data, &id) != nil {
+				c.log("watch maxitem: unexpected %s at %s", e.Type, e.Path)
+				continue
+			}
+			select {
+			case ch <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,100 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/api/apitest"
+)
+
+// next returns the next value from ch, failing the test if none comes
+// within a few seconds.
+func next[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	panic("unreachable")
+}
+
+func TestWatch(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(&api.Item{ID: 1, Type: "story", Score: 5})
+	c := srv.Client()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := c.WatchContext(ctx, "item/1")
+	score := func(e api.Event) int {
+		t.Helper()
+		var item api.Item
+		if e.Type != "put" || e.Path != "/" || json.Unmarshal(e.Data, &item) != nil {
+			t.Fatalf("got %s at %s: %s, want a put of the item", e.Type, e.Path, e.Data)
+		}
+		return item.Score
+	}
+	if s := score(next(t, events)); s != 5 {
+		t.Errorf("first event score = %d, want 5", s)
+	}
+	srv.AddItems(&api.Item{ID: 1, Type: "story", Score: 8})
+	if s := score(next(t, events)); s != 8 {
+		t.Errorf("score after change = %d, want 8", s)
+	}
+
+	// A dropped stream is reconnected, starting over with the whole value.
+	srv.DropStreams()
+	if s := score(next(t, events)); s != 8 {
+		t.Errorf("score after reconnect = %d, want 8", s)
+	}
+	if n := srv.Requests("/v0/item/1.json"); n != 2 {
+		t.Errorf("connections = %d, want 2", n)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchOffline(t *testing.T) {
+	c := api.New(api.WithOffline(true))
+	if _, ok := <-c.Watch("maxitem"); ok {
+		t.Error("Watch sent an event offline")
+	}
+}
+
+func TestWatchMaxItemAndUpdates(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(&api.Item{ID: 3, Type: "story"})
+	c := srv.Client()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	maxItems := c.WatchMaxItemContext(ctx)
+	updates := c.WatchUpdatesContext(ctx)
+	if id := next(t, maxItems); id != 3 {
+		t.Errorf("first max item = %d, want 3", id)
+	}
+	if u := next(t, updates); !slices.Equal(u.Items, []int{3}) {
+		t.Errorf("first updates = %v, want [3]", u.Items)
+	}
+
+	srv.AddItems(&api.Item{ID: 4, Type: "comment", Parent: 3})
+	if id := next(t, maxItems); id != 4 {
+		t.Errorf("max item = %d, want 4", id)
+	}
+	if u := next(t, updates); !slices.Equal(u.Items, []int{4, 3}) {
+		t.Errorf("updates = %v, want [4 3]", u.Items)
+	}
+}
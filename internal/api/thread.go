@@ -0,0 +1,227 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Comment is a node in a comment tree. The embedded Item holds the comment
+// itself; Replies holds its children in display order.
+type Comment struct {
+	*Item
+	Depth   int        `json:"depth"`
+	Replies []*Comment `json:"replies"`
+}
+
+// Thread is a story together with its full comment tree, and its options
+// if it is a poll.
+type Thread struct {
+	Story    *Item      `json:"story"`
+	Comments []*Comment `json:"comments"`
+	Options  []*Item    `json:"options,omitempty"` // a poll's options, in order
+}
+
+// Strategy selects the backend used to load comment threads.
+type Strategy string
+
+const (
+	// StrategyFirebase walks the tree one item at a time through the
+	// official API. It is always up to date but needs a request per comment.
+	StrategyFirebase Strategy = "firebase"
+	// StrategyAlgolia loads the whole tree in a single request from Algolia,
+	// whose copy may lag the live site by a few minutes.
+	StrategyAlgolia Strategy = "algolia"
+)
+
+// ParseStrategy converts a flag value into a Strategy.
+func ParseStrategy(s string) (Strategy, error) {
+	switch st := Strategy(s); st {
+	case StrategyFirebase, StrategyAlgolia:
+		return st, nil
+	}
+	return "", fmt.Errorf("unknown thread strategy %q (want %q or %q)", s, StrategyFirebase, StrategyAlgolia)
+}
+
+// Thread fetches a story and its full comment tree using the client's
+// strategy, falling back to the other backend if the first one fails.
+func (c *Client) Thread(id int) (*Thread, error) {
+	return c.ThreadContext(context.Background(), id)
+}
+
+// ThreadContext is like Thread but aborts when ctx is done.
+func (c *Client) ThreadContext(ctx context.Context, id int) (*Thread, error) {
+	primary, fallback := c.ThreadFirebaseContext, c.ThreadAlgoliaContext
+	if c.strategy == StrategyAlgolia {
+		primary, fallback = fallback, primary
+	}
+	t, err := primary(ctx, id)
+	if err == nil {
+		return t, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	t, ferr := fallback(ctx, id)
+	if ferr != nil {
+		return nil, errors.Join(err, ferr)
+	}
+	return t, nil
+}
+
+// ThreadFirebase fetches a story and recursively fetches every comment
+// beneath it. All fetches for the thread share a single concurrency limit;
+// comments that fail to load are omitted from the tree.
+func (c *Client) ThreadFirebase(id int) (*Thread, error) {
+	return c.ThreadFirebaseContext(context.Background(), id)
+}
+
+// ThreadFirebaseContext is like ThreadFirebase but stops fetching when ctx
+// is done.
+func (c *Client) ThreadFirebaseContext(ctx context.Context, id int) (*Thread, error) {
+	story, err := c.ItemContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if story.ID == 0 {
+		return nil, fmt.Errorf("item %d not found", id)
+	}
+	sem := make(chan struct{}, 20)
+	comments := c.comments(ctx, story.Kids, 0, sem)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t := &Thread{Story: story, Comments: comments}
+	c.addPollOptions(ctx, t)
+	return t, nil
+}
+
+// comments fetches the items in ids in parallel and then descends into their
+// kids. A semaphore slot is held only for the duration of a single request so
+// that waiting on a subtree never starves the requests it depends on.
+func (c *Client) comments(ctx context.Context, ids []int, depth int, sem chan struct{}) []*Comment {
+	if len(ids) == 0 {
+		return nil
+	}
+	nodes := make([]*Comment, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			item, err := c.ItemContext(ctx, id)
+			<-sem
+			if err != nil || item == nil || item.ID == 0 {
+				return
+			}
+			nodes[i] = &Comment{
+				Item:    item,
+				Depth:   depth,
+				Replies: c.comments(ctx, item.Kids, depth+1, sem),
+			}
+		}(i, id)
+	}
+	wg.Wait()
+
+	out := nodes[:0]
+	for _, n := range nodes {
+		if n != nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// algoliaItem is a node of the tree returned by Algolia's items endpoint.
+type algoliaItem struct {
+	ID         int           `json:"id"`
+	CreatedAtI int64         `json:"created_at_i"`
+	Type       string        `json:"type"`
+	Author     string        `json:"author"`
+	Title      string        `json:"title"`
+	URL        string        `json:"url"`
+	Text       string        `json:"text"`
+	Points     int           `json:"points"`
+	ParentID   int           `json:"parent_id"`
+	Children   []algoliaItem `json:"children"`
+}
+
+// ThreadAlgolia fetches a story and its full comment tree in a single request
+// to Algolia's items endpoint. Algolia orders replies by posting time rather
+// than by HN's ranking, and omits fields it does not index (e.g. dead).
+func (c *Client) ThreadAlgolia(id int) (*Thread, error) {
+	return c.ThreadAlgoliaContext(context.Background(), id)
+}
+
+// ThreadAlgoliaContext is like ThreadAlgolia but aborts when ctx is done.
+func (c *Client) ThreadAlgoliaContext(ctx context.Context, id int) (*Thread, error) {
+	var root algoliaItem
+	if err := c.get(ctx, fmt.Sprintf("%s/items/%d", c.algoliaURL, id), &root); err != nil {
+		return nil, err
+	}
+	story := root.item()
+	comments, n := algoliaComments(root.Children, 0)
+	story.Descendants = n
+	t := &Thread{Story: story, Comments: comments}
+	c.addPollOptions(ctx, t)
+	return t, nil
+}
+
+// item converts the node (without its children) to an Item.
+func (a algoliaItem) item() *Item {
+	item := &Item{
+		ID:     a.ID,
+		Type:   a.Type,
+		By:     a.Author,
+		Time:   a.CreatedAtI,
+		Text:   a.Text,
+		Parent: a.ParentID,
+		URL:    a.URL,
+		Score:  a.Points,
+		Title:  a.Title,
+		// Algolia keeps deleted comments in the tree with no author or text.
+		Deleted: a.Type == "comment" && a.Author == "",
+	}
+	for _, ch := range a.Children {
+		item.Kids = append(item.Kids, ch.ID)
+	}
+	return item
+}
+
+// algoliaComments converts Algolia children into a comment tree and returns
+// it along with the number of live comments it contains.
+func algoliaComments(children []algoliaItem, depth int) ([]*Comment, int) {
+	var out []*Comment
+	total := 0
+	for _, ch := range children {
+		replies, n := algoliaComments(ch.Children, depth+1)
+		cm := &Comment{Item: ch.item(), Depth: depth, Replies: replies}
+		if !cm.Deleted {
+			n++
+		}
+		total += n
+		out = append(out, cm)
+	}
+	return out, total
+}
+
+// Visible reports whether the comment should be shown to a reader. Deleted
+// and dead comments are hidden unless they still have visible replies, in
+// which case they are kept as placeholders so the thread structure survives.
+func (cm *Comment) Visible() bool {
+	if !cm.Deleted && !cm.Dead {
+		return true
+	}
+	for _, r := range cm.Replies {
+		if r.Visible() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/api/apitest"
+)
+
+// newThreadServer serves story 1 with this tree:
+//
+//	2
+//	├── 3
+//	│   └── 5
+//	└── 4 (deleted)
+//	6
+func newThreadServer() *apitest.Server {
+	srv := apitest.NewServer()
+	srv.AddItems(
+		&api.Item{ID: 1, Type: "story", Title: "thread", Kids: []int{2, 6}},
+		&api.Item{ID: 2, Type: "comment", By: "a", Text: "top", Parent: 1, Kids: []int{3, 4}},
+		&api.Item{ID: 3, Type: "comment", By: "b", Text: "reply", Parent: 2, Kids: []int{5}},
+		&api.Item{ID: 4, Type: "comment", Deleted: true, Parent: 2},
+		&api.Item{ID: 5, Type: "comment", By: "c", Text: "deeper", Parent: 3},
+		&api.Item{ID: 6, Type: "comment", By: "d", Text: "second", Parent: 1},
+	)
+	return srv
+}
+
+// shape flattens a comment tree into "id@depth" strings, depth-first.
+func shape(comments []*api.Comment) []string {
+	var out []string
+	for _, c := range comments {
+		out = append(out, fmt.Sprintf("%d@%d", c.ID, c.Depth))
+		out = append(out, shape(c.Replies)...)
+	}
+	return out
+}
+
+func TestThreadStrategies(t *testing.T) {
+	srv := newThreadServer()
+	defer srv.Close()
+	want := []string{"2@0", "3@1", "5@2", "4@1", "6@0"}
+
+	for _, s := range []api.Strategy{api.StrategyFirebase, api.StrategyAlgolia} {
+		t.Run(string(s), func(t *testing.T) {
+			th, err := srv.Client(api.WithStrategy(s)).Thread(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if th.Story.Title != "thread" {
+				t.Errorf("story title = %q", th.Story.Title)
+			}
+			if got := shape(th.Comments); !slices.Equal(got, want) {
+				t.Fatalf("tree = %v, want %v", got, want)
+			}
+			deleted := th.Comments[0].Replies[1]
+			if !deleted.Deleted || deleted.Visible() {
+				t.Errorf("comment 4: Deleted=%v Visible=%v, want deleted and hidden", deleted.Deleted, deleted.Visible())
+			}
+		})
+	}
+}
+
+func TestThreadFallback(t *testing.T) {
+	srv := newThreadServer()
+	defer srv.Close()
+	srv.FailNext("/api/v1/items/1", http.StatusNotFound)
+
+	th, err := srv.Client(api.WithStrategy(api.StrategyAlgolia)).Thread(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(th.Comments) != 2 {
+		t.Errorf("got %d top-level comments after falling back to Firebase, want 2", len(th.Comments))
+	}
+}
@@ -22,12 +22,20 @@ type Item struct {
 	Title       string `json:"title"`
 	Parts       []int  `json:"parts"`
 	Descendants int    `json:"descendants"`
+
+	// The story a comment belongs to. Only search results and a user's
+	// submissions fill these in.
+	StoryID    int    `json:"story_id,omitempty"`
+	StoryTitle string `json:"story_title,omitempty"`
+	StoryURL   string `json:"story_url,omitempty"`
 }
 
 // Age returns a human-readable age string.
-func (i Item) Age() string {
-	t := time.Unix(i.Time, 0)
-	d := time.Since(t)
+func (i Item) Age() string { return Age(i.Time) }
+
+// Age returns a human-readable age string for a Unix timestamp.
+func Age(unix int64) string {
+	d := time.Since(time.Unix(unix, 0))
 	switch {
 	case d < time.Minute:
 		return "just now"
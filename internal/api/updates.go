@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"fmt"
+)
+
+// Updates lists the items and profiles that changed recently, as reported
+// by the updates endpoint. HN only keeps the last few minutes of changes.
+type Updates struct {
+	Items    []int    `json:"items"`
+	Profiles []string `json:"profiles"`
+}
+
+// Updates fetches the recently changed items and profiles. It is never
+// answered from the cache.
+func (c *Client) Updates() (*Updates, error) {
+	return c.UpdatesContext(context.Background())
+}
+
+// UpdatesContext is like Updates but aborts when ctx is done.
+func (c *Client) UpdatesContext(ctx context.Context) (*Updates, error) {
+	var u Updates
+	if err := c.get(Fresh(ctx), fmt.Sprintf("%s/updates.json", c.baseURL), &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// MaxItem fetches the largest item ID so far. Every item posted since an
+// earlier call has an ID between the two results. It is never answered
+// from the cache.
+func (c *Client) MaxItem() (int, error) {
+	return c.MaxItemContext(context.Background())
+}
+
+// MaxItemContext is like MaxItem but aborts when ctx is done.
+func (c *Client) MaxItemContext(ctx context.Context) (int, error) {
+	var id int
+	if err := c.get(Fresh(ctx), fmt.Sprintf("%s/maxitem.json", c.baseURL), &id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// RefreshItems fetches the items with the given IDs in parallel, in order,
+// bypassing the cache. See ItemsContext for how errors are reported.
+func (c *Client) RefreshItems(ids []int) ([]*Item, error) {
+	return c.RefreshItemsContext(context.Background(), ids)
+}
+
+// RefreshItemsContext is like RefreshItems but stops fetching when ctx is done.
+func (c *Client) RefreshItemsContext(ctx context.Context, ids []int) ([]*Item, error) {
+	return c.items(ctx, ids, c.freshItem)
+}
+
+// freshItem fetches the item with the given ID, bypassing the cache.
+func (c *Client) freshItem(ctx context.Context, id int) (*Item, error) {
+	var item Item
+	if err := c.get(Fresh(ctx), fmt.Sprintf("%s/item/%d.json", c.baseURL, id), &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// RepliesContext fetches the comment trees under ids, as ThreadFirebase
+// does for a whole thread, numbering their depth from depth. It is for
+// adding replies that arrived after a thread was loaded, so it bypasses
+// the cache.
+func (c *Client) RepliesContext(ctx context.Context, ids []int, depth int) []*Comment {
+	return c.comments(Fresh(ctx), ids, depth, make(chan struct{}, 20))
+}
+
+// SettleWindow is how close to the largest item ID an ID must be for
+// NewItems to wait for it when it holds nothing yet: the newest IDs are
+// sometimes answered with null for a moment before their items appear.
+const SettleWindow = 20
+
+// NewItems fetches up to limit of the items posted after the item with ID
+// after, in ID order. See NewItemsContext.
+func (c *Client) NewItems(after, limit int) ([]*Item, int, error) {
+	return c.NewItemsContext(context.Background(), after, limit)
+}
+
+// NewItemsContext is like NewItems but stops fetching when ctx is done.
+// It also returns the cursor to pass as after next time: the ID of the
+// last item handled. Items are fetched in parallel, but the cursor never
+// moves past one that failed to load, so that following the cursor misses
+// nothing; the failure is returned along with the items before it. The
+// cursor likewise stops short of an ID that holds nothing within
+// SettleWindow of the largest ID, until a later call finds it filled;
+// further down, such IDs are passed over.
+func (c *Client) NewItemsContext(ctx context.Context, after, limit int) ([]*Item, int, error) {
+	top, err := c.MaxItemContext(ctx)
+	if err != nil {
+		return nil, after, err
+	}
+	end := min(top, after+limit)
+	if end <= after {
+		return nil, after, nil
+	}
+
+	ids := make([]int, end-after)
+	for i := range ids {
+		ids[i] = after + 1 + i
+	}
+	items, errs, err := c.fetchEach(ctx, ids, c.freshItem)
+	if err != nil {
+		return nil, after, err
+	}
+
+	var out []*Item
+	cursor := after
+	for i, item := range items {
+		if errs[i] != nil {
+			return out, cursor, errs[i]
+		}
+		id := ids[i]
+		if item.ID == 0 && top-id < SettleWindow {
+			return out, cursor, nil // perhaps not filled yet
+		}
+		cursor = id
+		if item.ID != 0 {
+			out = append(out, item)
+		}
+	}
+	return out, cursor, nil
+}
@@ -0,0 +1,153 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/api/apitest"
+)
+
+func TestUpdatesAndMaxItem(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(&api.Item{ID: 7, Type: "story"}, &api.Item{ID: 3, Type: "comment"})
+	srv.AddUsers(&api.User{ID: "pg"})
+	c := srv.Client()
+
+	u, err := c.Updates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(u.Items, []int{3, 7}) || !slices.Equal(u.Profiles, []string{"pg"}) {
+		t.Errorf("Updates = %v %v, want [3 7] [pg]", u.Items, u.Profiles)
+	}
+	if id, err := c.MaxItem(); err != nil || id != 7 {
+		t.Errorf("MaxItem = %d, %v, want 7", id, err)
+	}
+}
+
+func TestRefreshItemsBypassesCache(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(&api.Item{ID: 1, Type: "story", Score: 10})
+	c := srv.Client(api.WithCache(api.NewCache(t.TempDir(), 0)))
+
+	if _, err := c.Item(1); err != nil {
+		t.Fatal(err)
+	}
+	srv.AddItems(&api.Item{ID: 1, Type: "story", Score: 25})
+
+	if item, _ := c.Item(1); item.Score != 10 {
+		t.Fatalf("cached Item score = %d, want 10", item.Score)
+	}
+	items, err := c.RefreshItems([]int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Score != 25 {
+		t.Fatalf("RefreshItems = %v, want the new score 25", items)
+	}
+	if item, _ := c.Item(1); item.Score != 25 {
+		t.Errorf("Item after refresh = %d, want the cache updated to 25", item.Score)
+	}
+}
+
+func TestRepliesBypassesCache(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(&api.Item{ID: 2, Type: "comment", Parent: 1})
+	c := srv.Client(api.WithCache(api.NewCache(t.TempDir(), 0)))
+
+	if _, err := c.Item(2); err != nil {
+		t.Fatal(err)
+	}
+	srv.AddItems(&api.Item{ID: 2, Type: "comment", Parent: 1, Kids: []int{3}},
+		&api.Item{ID: 3, Type: "comment", Parent: 2})
+
+	replies := c.RepliesContext(context.Background(), []int{2}, 1)
+	if len(replies) != 1 || len(replies[0].Replies) != 1 || replies[0].Replies[0].ID != 3 {
+		t.Fatalf("RepliesContext = %v, want comment 2 with its new reply 3", replies)
+	}
+	if d := replies[0].Replies[0].Depth; d != 2 {
+		t.Errorf("reply depth = %d, want 2", d)
+	}
+}
+
+func TestNewItems(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	for id := 1; id <= 6; id++ {
+		if id != 4 { // a gap well below the newest ID is passed over
+			srv.AddItems(&api.Item{ID: id, Type: "comment"})
+		}
+	}
+	top := 6 + api.SettleWindow
+	srv.AddItems(&api.Item{ID: top, Type: "story"})
+	c := srv.Client(api.WithRetry(api.RetryPolicy{}))
+
+	items, cursor, err := c.NewItems(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(items); !slices.Equal(got, []int{2, 3}) || cursor != 4 {
+		t.Errorf("NewItems(1, 3) = %v, cursor %d; want [2 3], cursor 4", got, cursor)
+	}
+
+	srv.FailNext("/v0/item/6.json", http.StatusInternalServerError)
+	items, cursor, err = c.NewItems(cursor, 2)
+	if err == nil {
+		t.Fatal("NewItems succeeded with item 6 failing")
+	}
+	if got := ids(items); !slices.Equal(got, []int{5}) || cursor != 5 {
+		t.Errorf("NewItems(4, 2) = %v, cursor %d; want [5], cursor 5 short of the failure", got, cursor)
+	}
+
+	items, cursor, err = c.NewItems(cursor, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(items); !slices.Equal(got, []int{6}) || cursor != 6 {
+		t.Errorf("NewItems(5, 1) = %v, cursor %d; want [6], cursor 6", got, cursor)
+	}
+
+	items, cursor, err = c.NewItems(top-1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(items); !slices.Equal(got, []int{top}) || cursor != top {
+		t.Errorf("NewItems(%d, 10) = %v, cursor %d; want [%d], cursor %[4]d", top-1, got, cursor, top)
+	}
+	if items, cursor, _ := c.NewItems(cursor, 10); len(items) != 0 || cursor != top {
+		t.Errorf("NewItems at the end = %v, cursor %d; want nothing, cursor %d", ids(items), cursor, top)
+	}
+}
+
+func TestNewItemsWaitsForNewestIDs(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	// Item 3 is answered with null, as the newest IDs briefly are.
+	srv.AddItems(&api.Item{ID: 1, Type: "story"}, &api.Item{ID: 2, Type: "comment"}, &api.Item{ID: 4, Type: "comment"})
+	c := srv.Client(api.WithCache(api.NewCache(t.TempDir(), 0)))
+
+	for range 2 {
+		items, cursor, err := c.NewItems(0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(items); !slices.Equal(got, []int{1, 2}) || cursor != 2 {
+			t.Fatalf("NewItems(0, 10) = %v, cursor %d; want [1 2], cursor 2 short of the empty ID", got, cursor)
+		}
+	}
+
+	srv.AddItems(&api.Item{ID: 3, Type: "comment"})
+	items, cursor, err := c.NewItems(2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(items); !slices.Equal(got, []int{3, 4}) || cursor != 4 {
+		t.Errorf("NewItems(2, 10) once item 3 appeared = %v, cursor %d; want [3 4], cursor 4", got, cursor)
+	}
+}
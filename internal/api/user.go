@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// maxDepth bounds the walk from a comment up to its story, in case of a
+// cycle in bad data.
+const maxDepth = 100
+
+// Submissions fetches n of a user's submissions, newest first, starting
+// offset items into their history. See SubmissionsContext.
+func (c *Client) Submissions(user *User, offset, n int) ([]*Item, error) {
+	return c.SubmissionsContext(context.Background(), user, offset, n)
+}
+
+// SubmissionsContext fetches user.Submitted[offset:offset+n] concurrently.
+// Deleted and dead items are left out, as are poll options, which belong
+// to their poll. Comments get StoryID, StoryTitle and StoryURL filled in
+// from the story they were posted under. Items that fail to load are
+// skipped and the first error is returned with the rest.
+func (c *Client) SubmissionsContext(ctx context.Context, user *User, offset, n int) ([]*Item, error) {
+	if offset >= len(user.Submitted) {
+		return nil, nil
+	}
+	fetched, err := c.ItemsContext(ctx, user.Submitted[offset:min(offset+n, len(user.Submitted))])
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	items := make([]*Item, 0, len(fetched))
+	for _, item := range fetched {
+		if item.ID == 0 || item.Deleted || item.Dead || item.Type == "pollopt" {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	// Comments in the same thread share ancestors, so each is fetched once.
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		seen  = make(map[int]*Item)
+		sem   = make(chan struct{}, 20)
+		first error
+	)
+	parent := func(id int) (*Item, error) {
+		mu.Lock()
+		item, ok := seen[id]
+		mu.Unlock()
+		if ok {
+			return item, nil
+		}
+		item, err := c.ItemContext(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		seen[id] = item
+		mu.Unlock()
+		return item, nil
+	}
+	for _, item := range items {
+		if item.Type != "comment" {
+			continue
+		}
+		wg.Add(1)
+		go func(item *Item) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			story, err := storyOf(item, parent)
+			if err != nil && !errors.Is(err, ErrNotCached) {
+				mu.Lock()
+				if first == nil {
+					first = err
+				}
+				mu.Unlock()
+			}
+			if story == nil {
+				return
+			}
+			item.StoryID, item.StoryTitle, item.StoryURL = story.ID, story.Title, story.URL
+		}(item)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err == nil {
+		err = first
+	}
+	return items, err
+}
+
+// storyOf follows a comment's parents up to the item it was posted under,
+// usually a story but possibly a poll or a job. It returns nil if an
+// ancestor is missing.
+func storyOf(item *Item, parent func(id int) (*Item, error)) (*Item, error) {
+	for range maxDepth {
+		if item.Type != "comment" || item.Parent == 0 {
+			return item, nil
+		}
+		p, err := parent(item.Parent)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil || p.ID == 0 {
+			return nil, nil
+		}
+		item = p
+	}
+	return nil, nil
+}
@@ -0,0 +1,48 @@
+package api_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/api/apitest"
+)
+
+func TestSubmissions(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(
+		&api.Item{ID: 1, Type: "story", Title: "Show HN: a thing", URL: "https://example.com"},
+		&api.Item{ID: 2, Type: "comment", Parent: 1, By: "someone"},
+		&api.Item{ID: 3, Type: "comment", Parent: 2, By: "pg"},
+		&api.Item{ID: 4, Type: "poll", Title: "Tabs or spaces?", By: "pg"},
+		&api.Item{ID: 5, Type: "pollopt", Poll: 4, By: "pg"},
+		&api.Item{ID: 6, Type: "story", By: "pg", Deleted: true},
+		&api.Item{ID: 7, Type: "story", Title: "Essay", By: "pg"},
+	)
+	user := &api.User{ID: "pg", Submitted: []int{7, 6, 5, 4, 3}}
+	c := srv.Client()
+
+	items, err := c.Submissions(user, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(items), []int{7, 4, 3}; !slices.Equal(got, want) {
+		t.Fatalf("Submissions = %v, want %v without deleted items and poll options", got, want)
+	}
+	comment := items[2]
+	if comment.StoryID != 1 || comment.StoryTitle != "Show HN: a thing" || comment.StoryURL != "https://example.com" {
+		t.Errorf("comment story = %d %q %q, want the root story", comment.StoryID, comment.StoryTitle, comment.StoryURL)
+	}
+
+	items, err = c.Submissions(user, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(items), []int{4, 3}; !slices.Equal(got, want) {
+		t.Errorf("Submissions from offset 3 = %v, want %v", got, want)
+	}
+	if items, _ := c.Submissions(user, 9, 10); len(items) != 0 {
+		t.Errorf("Submissions past the end = %v, want none", ids(items))
+	}
+}
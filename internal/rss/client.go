@@ -1,23 +1,27 @@
 package rss
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
-const hnrssBase = "https://hnrss.org"
+// DefaultBaseURL is the hnrss.org service root.
+const DefaultBaseURL = "https://hnrss.org"
 
 // Item is an RSS feed item from hnrss.org.
 type Item struct {
-	Title    string `xml:"title"`
-	Link     string `xml:"link"`
-	Comments string `xml:"comments"`
-	PubDate  string `xml:"pubDate"`
-	Creator  string `xml:"creator"`
-	GUID     string `xml:"guid"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Comments    string `xml:"comments"`
+	PubDate     string `xml:"pubDate"`
+	Creator     string `xml:"creator"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
 }
 
 type channel struct {
@@ -30,24 +34,49 @@ type rss struct {
 
 // Client is an hnrss.org client.
 type Client struct {
-	http *http.Client
+	http    *http.Client
+	baseURL string
 }
 
-// New returns a new Client.
-func New() *Client {
-	return &Client{http: &http.Client{Timeout: 10 * time.Second}}
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL points the client at an hnrss-compatible server.
+func WithBaseURL(u string) Option {
+	return func(c *Client) { c.baseURL = strings.TrimSuffix(u, "/") }
+}
+
+// WithHTTPClient makes the client send requests through hc.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// New returns a new Client configured by opts.
+func New(opts ...Option) *Client {
+	c := &Client{http: &http.Client{Timeout: 10 * time.Second}, baseURL: DefaultBaseURL}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-func (c *Client) fetch(endpoint string, params url.Values) ([]Item, error) {
-	u := fmt.Sprintf("%s/%s", hnrssBase, endpoint)
+func (c *Client) fetch(ctx context.Context, endpoint string, params url.Values) ([]Item, error) {
+	u := fmt.Sprintf("%s/%s", c.baseURL, endpoint)
 	if len(params) > 0 {
 		u += "?" + params.Encode()
 	}
-	resp, err := c.http.Get(u)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("hnrss: %s", resp.Status)
+	}
 	var feed rss
 	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
 		return nil, err
@@ -57,12 +86,28 @@ func (c *Client) fetch(endpoint string, params url.Values) ([]Item, error) {
 
 // Search returns RSS items matching query q (optionally limited to n results).
 func (c *Client) Search(q string, n int) ([]Item, error) {
+	return c.SearchContext(context.Background(), q, n)
+}
+
+// SearchContext is like Search but aborts when ctx is done.
+func (c *Client) SearchContext(ctx context.Context, q string, n int) ([]Item, error) {
 	params := url.Values{"q": {q}, "count": {fmt.Sprint(n)}}
-	return c.fetch("newest", params)
+	return c.fetch(ctx, "newest", params)
 }
 
-// Feed returns items from a named feed (frontpage, newest, ask, show, jobs, best).
-func (c *Client) Feed(name string, n int) ([]Item, error) {
-	params := url.Values{"count": {fmt.Sprint(n)}}
-	return c.fetch(name, params)
+// Feed returns items from a named feed (frontpage, newest, ask, show, jobs,
+// best, ...). filters are passed through as hnrss query parameters, e.g.
+// points=100, comments=25 or q=rust.
+func (c *Client) Feed(name string, n int, filters url.Values) ([]Item, error) {
+	return c.FeedContext(context.Background(), name, n, filters)
+}
+
+// FeedContext is like Feed but aborts when ctx is done.
+func (c *Client) FeedContext(ctx context.Context, name string, n int, filters url.Values) ([]Item, error) {
+	params := url.Values{}
+	for k, v := range filters {
+		params[k] = v
+	}
+	params.Set("count", fmt.Sprint(n))
+	return c.fetch(ctx, name, params)
 }
@@ -0,0 +1,81 @@
+package rss
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+)
+
+var (
+	pointsRe   = regexp.MustCompile(`Points:\s*(\d+)`)
+	commentsRe = regexp.MustCompile(`# Comments:\s*(\d+)`)
+)
+
+// ID returns the HN item ID the entry refers to, taken from its comments
+// link or, failing that, its GUID. It returns 0 if neither holds one.
+func (i Item) ID() int {
+	for _, s := range []string{i.Comments, i.GUID} {
+		u, err := url.Parse(strings.TrimSpace(s))
+		if err != nil {
+			continue
+		}
+		if id, err := strconv.Atoi(u.Query().Get("id")); err == nil {
+			return id
+		}
+	}
+	return 0
+}
+
+// Time returns the entry's publication time, or the zero time if PubDate
+// can't be parsed.
+func (i Item) Time() time.Time {
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123} {
+		if t, err := time.Parse(layout, strings.TrimSpace(i.PubDate)); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// HNItem converts the entry into an api.Item so it can be shown anywhere a
+// Firebase story can. Points and comment counts come from the statistics
+// hnrss embeds in the description. Self posts (Ask HN etc.), whose link is
+// the discussion itself, get no URL.
+func (i Item) HNItem() *api.Item {
+	item := &api.Item{
+		ID:    i.ID(),
+		Type:  "story",
+		By:    i.Creator,
+		Title: i.Title,
+		URL:   i.Link,
+	}
+	if t := i.Time(); !t.IsZero() {
+		item.Time = t.Unix()
+	}
+	if item.URL == i.Comments || strings.HasPrefix(item.URL, "https://news.ycombinator.com/item?id=") {
+		item.URL = ""
+	}
+	if m := pointsRe.FindStringSubmatch(i.Description); m != nil {
+		item.Score, _ = strconv.Atoi(m[1])
+	}
+	if m := commentsRe.FindStringSubmatch(i.Description); m != nil {
+		item.Descendants, _ = strconv.Atoi(m[1])
+	}
+	return item
+}
+
+// HNItems converts entries with HNItem, dropping any that don't refer to an
+// HN item.
+func HNItems(entries []Item) []*api.Item {
+	items := make([]*api.Item, 0, len(entries))
+	for _, e := range entries {
+		if item := e.HNItem(); item.ID != 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
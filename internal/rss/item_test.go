@@ -0,0 +1,46 @@
+package rss
+
+import "testing"
+
+func TestHNItem(t *testing.T) {
+	entry := Item{
+		Title:    "Show HN: A thing",
+		Link:     "https://example.com/thing",
+		Comments: "https://news.ycombinator.com/item?id=41234567",
+		PubDate:  "Tue, 03 Sep 2024 14:05:06 +0000",
+		Creator:  "maker",
+		GUID:     "https://news.ycombinator.com/item?id=41234567",
+		Description: `<p>Article URL: <a href="https://example.com/thing">https://example.com/thing</a></p>
+<p>Comments URL: <a href="https://news.ycombinator.com/item?id=41234567">https://news.ycombinator.com/item?id=41234567</a></p>
+<p>Points: 128</p>
+<p># Comments: 37</p>`,
+	}
+	item := entry.HNItem()
+	if item.ID != 41234567 || item.By != "maker" || item.URL != "https://example.com/thing" {
+		t.Errorf("HNItem() = %+v", item)
+	}
+	if item.Time != 1725372306 {
+		t.Errorf("Time = %d, want 1725372306", item.Time)
+	}
+	if item.Score != 128 || item.Descendants != 37 {
+		t.Errorf("Score, Descendants = %d, %d; want 128, 37", item.Score, item.Descendants)
+	}
+}
+
+func TestHNItemSelfPost(t *testing.T) {
+	entry := Item{
+		Title:    "Ask HN: Anything?",
+		Link:     "https://news.ycombinator.com/item?id=7",
+		Comments: "https://news.ycombinator.com/item?id=7",
+	}
+	if item := entry.HNItem(); item.URL != "" || item.ID != 7 {
+		t.Errorf("HNItem() = %+v, want ID 7 and no URL", item)
+	}
+}
+
+func TestHNItemsDropsUnknownIDs(t *testing.T) {
+	items := HNItems([]Item{{Title: "no id"}, {GUID: "https://news.ycombinator.com/item?id=3"}})
+	if len(items) != 1 || items[0].ID != 3 {
+		t.Errorf("HNItems() = %v, want just item 3", items)
+	}
+}
@@ -1,9 +1,13 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"sync"
+	"slices"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/hexadecimoose/hncli/internal/api"
 )
@@ -17,18 +21,48 @@ const (
 	ViewUser
 )
 
-// App is the root bubbletea model for the interactive browser.
+// Loader fetches a story list. It should give up when ctx is cancelled.
+// A loader for a long list may return just its first items together with
+// the IDs of the whole list; the rest are then fetched as the user scrolls.
+type Loader func(ctx context.Context) (items []*api.Item, ids []int, err error)
+
+// searchHits is how many results a search from the / prompt shows.
+const searchHits = 30
+
+// App is the root bubbletea model for the interactive browser. The active
+// screen lives in view and the model for it; screens navigated away from
+// are kept in back, and those backed out of in forward, like a browser.
 type App struct {
 	apiClient *api.Client
-	loader    func() ([]*api.Item, error)
+	loader    Loader // refreshes list
 	view      View
 	list      ListModel
 	comments  CommentsModel
 	user      UserModel
+	back      []screen
+	forward   []screen
+	tabs      []tabState // empty unless started with RunTabs
+	active    int        // index into tabs
+	size      tea.WindowSizeMsg
+
+	ctx    context.Context    // parent of every load; cancelled when the program exits
+	cancel context.CancelFunc // cancels the load in flight, if any
+	gen    int                // bumped whenever a load is started or abandoned
+
+	interval time.Duration       // how often to check for changes, 0 for never
+	maxItem  int                 // largest item ID looked through at the last check
+	stream   bool                // follow the updates stream instead of checking every interval
+	updates  <-chan *api.Updates // the updates stream, nil if not streaming
+	pending  []int               // IDs streamed since the last check
+	polling  bool                // a check for changes is running
+	checking []int               // the streamed IDs that check is looking at
+
+	showHelp bool   // the full help is showing over the screen
+	prefix   string // the first key of a sequence, while waiting for the second
 }
 
 // NewApp creates a new App ready to show the given story list.
-func NewApp(client *api.Client, title string, loader func() ([]*api.Item, error)) *App {
+func NewApp(ctx context.Context, client *api.Client, title string, loader Loader) *App {
 	app := &App{
 		apiClient: client,
 		loader:    loader,
@@ -36,160 +70,325 @@ func NewApp(client *api.Client, title string, loader func() ([]*api.Item, error)
 		list:      NewListModel(title),
 		comments:  NewCommentsModel(),
 		user:      NewUserModel(),
+		ctx:       ctx,
 	}
 	app.list.loading = true
 	app.comments.loading = false
 	return app
 }
 
+// startLoad cancels any load still in flight and returns the context for a
+// new one, so that navigating away abandons fetches nobody will look at.
+func (a *App) startLoad() context.Context {
+	a.cancelLoad()
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancel = cancel
+	return ctx
+}
+
+// cancelLoad cancels the load in flight, if any. Its result will be dropped
+// even if it has already been sent.
+func (a *App) cancelLoad() {
+	a.gen++
+	if a.cancel != nil {
+		a.cancel()
+		a.cancel = nil
+	}
+}
+
+// loaded carries the result of a load together with the generation it was
+// started in.
+type loaded struct {
+	gen int
+	msg tea.Msg
+}
+
+// track tags the result of cmd with the current load generation, so that
+// it is only delivered if no other load has been started or abandoned since.
+func (a *App) track(cmd tea.Cmd) tea.Cmd {
+	gen := a.gen
+	return func() tea.Msg { return loaded{gen: gen, msg: cmd()} }
+}
+
 // LoadCmd returns a command that fetches stories and sends StoriesLoaded.
-func LoadCmd(loader func() ([]*api.Item, error)) tea.Cmd {
+// In offline mode the message also records how old the cached data is.
+func LoadCmd(ctx context.Context, client *api.Client, loader Loader) tea.Cmd {
 	return func() tea.Msg {
-		items, err := loader()
-		return StoriesLoaded{Items: items, Err: err}
+		items, ids, err := loader(ctx)
+		msg := StoriesLoaded{Items: items, IDs: ids, Err: err}
+		if client.Offline() {
+			msg.StaleSince = client.StaleSince()
+		}
+		return msg
 	}
 }
 
-// LoadItemCmd fetches a story and its first-level comments in parallel.
-func LoadItemCmd(client *api.Client, id int) tea.Cmd {
+// LoadMoreCmd fetches the items with the given IDs and sends MoreLoaded.
+func LoadMoreCmd(ctx context.Context, client *api.Client, ids []int) tea.Cmd {
 	return func() tea.Msg {
-		story, err := client.Item(id)
+		items, err := client.ItemsContext(ctx, ids)
+		return MoreLoaded{Items: items, Err: err}
+	}
+}
+
+// LoadItemCmd fetches a story and its full comment tree, and its options
+// if it is a poll.
+func LoadItemCmd(ctx context.Context, client *api.Client, id int) tea.Cmd {
+	return func() tea.Msg {
+		thread, err := client.ThreadContext(ctx, id)
 		if err != nil {
 			return ItemLoaded{Err: err}
 		}
-		if len(story.Kids) == 0 {
-			return ItemLoaded{Story: story, Comments: nil}
-		}
-
-		// Fetch first-level comments in parallel (cap at 50).
-		limit := len(story.Kids)
-		if limit > 50 {
-			limit = 50
-		}
-		kids := story.Kids[:limit]
-		comments := make([]*api.Item, len(kids))
-		var mu sync.Mutex
-		var wg sync.WaitGroup
-		sem := make(chan struct{}, 20)
-		for i, kid := range kids {
-			wg.Add(1)
-			go func(i, kid int) {
-				defer wg.Done()
-				sem <- struct{}{}
-				defer func() { <-sem }()
-				c, e := client.Item(kid)
-				if e == nil {
-					mu.Lock()
-					comments[i] = c
-					mu.Unlock()
-				}
-			}(i, kid)
-		}
-		wg.Wait()
-		return ItemLoaded{Story: story, Comments: comments}
+		return ItemLoaded{Story: thread.Story, Comments: thread.Comments, Options: thread.Options}
 	}
 }
 
-// LoadUserCmd fetches a user profile and their recent submissions.
-func LoadUserCmd(client *api.Client, username string) tea.Cmd {
+// LoadUserCmd fetches a user profile and the first page of their
+// submissions.
+func LoadUserCmd(ctx context.Context, client *api.Client, username string) tea.Cmd {
 	return func() tea.Msg {
-		user, err := client.User(username)
+		user, err := client.UserContext(ctx, username)
 		if err != nil {
 			return UserLoaded{Err: err}
 		}
-		// Fetch up to 10 recent story submissions.
-		var items []*api.Item
-		count := 0
-		for _, id := range user.Submitted {
-			if count >= 10 {
-				break
-			}
-			item, e := client.Item(id)
-			if e == nil && item != nil && item.Type == "story" && !item.Dead && !item.Deleted {
-				items = append(items, item)
-				count++
-			}
+		if user.ID == "" {
+			return UserLoaded{} // no such user
+		}
+		items, err := client.SubmissionsContext(ctx, user, 0, UserPage)
+		if errors.Is(err, context.Canceled) {
+			return UserLoaded{Err: err}
 		}
+		// Submissions that failed to load are skipped.
 		return UserLoaded{User: user, Items: items}
 	}
 }
 
-func (a *App) Init() tea.Cmd { return nil }
+// LoadActivityCmd fetches n of a user's submissions, starting offset items
+// into their history, and sends ActivityLoaded.
+func LoadActivityCmd(ctx context.Context, client *api.Client, user *api.User, offset, n int) tea.Cmd {
+	return func() tea.Msg {
+		items, err := client.SubmissionsContext(ctx, user, offset, n)
+		return ActivityLoaded{Items: items, Err: err}
+	}
+}
+
+func (a *App) Init() tea.Cmd { return tea.Batch(a.tick(), a.next()) }
+
+// SearchLoader returns a loader that runs an Algolia story search for query,
+// for the first hits stories matching it.
+func SearchLoader(client *api.Client, query string, hits int) Loader {
+	return func(ctx context.Context) ([]*api.Item, []int, error) {
+		res, err := client.SearchPage(ctx, api.SearchOptions{Query: query, HitsPerPage: hits})
+		if err != nil {
+			return nil, nil, err
+		}
+		return res.Items, nil, nil
+	}
+}
 
 func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if msg.String() == "q" && a.view == ViewList {
+		// ctrl+c quits whatever the keymap says, even from the search prompt.
+		if msg.String() == "ctrl+c" || key.Matches(msg, keymap.Quit) && !a.searching() && !a.showHelp {
+			a.cancelLoad()
 			return a, tea.Quit
 		}
-		if msg.String() == "ctrl+c" {
-			return a, tea.Quit
+		if a.showHelp {
+			// Any other key closes the help.
+			a.showHelp = false
+			return a, nil
 		}
-		if msg.String() == "r" {
-			switch a.view {
-			case ViewList:
-				if a.loader != nil {
-					a.list.loading = true
-					a.list.items = nil
-					a.list.cursor = 0
-					a.list.offset = 0
-					return a, LoadCmd(a.loader)
-				}
-			case ViewComments:
-				if a.comments.story != nil {
-					id := a.comments.story.ID
-					w, h := a.comments.width, a.comments.height
-					a.comments = NewCommentsModel()
-					a.comments.width, a.comments.height = w, h
-					return a, LoadItemCmd(a.apiClient, id)
-				}
-			case ViewUser:
-				if a.user.user != nil {
-					username := a.user.user.ID
-					w, h := a.user.width, a.user.height
-					a.user = NewUserModel()
-					a.user.width, a.user.height = w, h
-					return a, LoadUserCmd(a.apiClient, username)
-				}
+		if a.searching() {
+			// The search prompt takes every other key.
+			m, cmd := a.list.Update(msg)
+			a.list = m
+			return a, cmd
+		}
+		if first := a.prefix; first != "" {
+			// A key that doesn't finish the sequence counts by itself.
+			a.prefix = ""
+			if seq, ok := seqMsg(first, msg); ok {
+				return a.Update(seq)
+			}
+		} else if startsSeq(a.view, msg.String()) {
+			a.prefix = msg.String()
+			return a, nil
+		}
+		switch {
+		case key.Matches(msg, keymap.Help):
+			a.showHelp = true
+			return a, nil
+		case key.Matches(msg, keymap.Refresh):
+			return a, a.refresh()
+		case key.Matches(msg, keymap.Forward):
+			return a, a.goForward()
+		case key.Matches(msg, keymap.NextTab):
+			// A profile uses the same keys for its own tabs.
+			if len(a.tabs) > 0 && a.view != ViewUser {
+				return a, a.switchTab((a.active + 1) % len(a.tabs))
+			}
+		case key.Matches(msg, keymap.PrevTab):
+			if len(a.tabs) > 0 && a.view != ViewUser {
+				return a, a.switchTab((a.active + len(a.tabs) - 1) % len(a.tabs))
 			}
+		case key.Matches(msg, keymap.GoToTab):
+			// The nth key goes to the nth tab.
+			if i := slices.Index(keymap.GoToTab.Keys(), msg.String()); i >= 0 && len(a.tabs) > 0 {
+				return a, a.switchTab(i)
+			}
+		}
+
+	case loaded:
+		if msg.gen != a.gen {
+			return a, nil // the user has moved on
 		}
+		return a.Update(msg.msg)
 
+	// Results of cancelled loads are dropped: the user has moved on.
 	case StoriesLoaded:
+		if errors.Is(msg.Err, context.Canceled) {
+			return a, nil
+		}
+		m, cmd := a.list.Update(msg)
+		a.list = m
+		return a, cmd
+
+	case MoreLoaded:
+		if errors.Is(msg.Err, context.Canceled) {
+			return a, nil
+		}
 		m, cmd := a.list.Update(msg)
 		a.list = m
 		return a, cmd
 
+	case LoadMore:
+		return a, a.track(LoadMoreCmd(a.startLoad(), a.apiClient, msg.IDs))
+
+	case ActivityLoaded:
+		if errors.Is(msg.Err, context.Canceled) {
+			return a, nil
+		}
+		m, cmd := a.user.Update(msg)
+		a.user = m
+		return a, cmd
+
+	case LoadActivity:
+		return a, a.track(LoadActivityCmd(a.startLoad(), a.apiClient, a.user.user, msg.Offset, msg.N))
+
 	case ItemLoaded:
-		a.view = ViewComments
+		if errors.Is(msg.Err, context.Canceled) {
+			return a, nil
+		}
 		m, cmd := a.comments.Update(msg)
 		a.comments = m
 		return a, cmd
 
 	case UserLoaded:
-		a.view = ViewUser
+		if errors.Is(msg.Err, context.Canceled) {
+			return a, nil
+		}
 		m, cmd := a.user.Update(msg)
 		a.user = m
 		return a, cmd
 
 	case OpenItem:
+		a.navigate()
 		// Preserve terminal dimensions — NewCommentsModel() defaults to 80×24
 		// which would cause buildLines() to wrap at 80 cols even on wider terminals.
 		w, h := a.comments.width, a.comments.height
 		a.comments = NewCommentsModel()
 		a.comments.width, a.comments.height = w, h
-		return a, LoadItemCmd(a.apiClient, msg.ID)
+		a.comments.id = msg.ID
+		a.comments.focus = msg.Focus
+		a.view = ViewComments
+		return a, a.reload()
 
-	case BackMsg:
+	case OpenUser:
+		a.navigate()
+		w, h := a.user.width, a.user.height
+		a.user = NewUserModel()
+		a.user.width, a.user.height = w, h
+		a.user.username = msg.Username
+		a.view = ViewUser
+		return a, a.reload()
+
+	case SearchMsg:
+		a.navigate()
+		w, h, sw := a.list.width, a.list.height, a.list.search.Width
+		a.list = NewListModel(fmt.Sprintf("Search: %q", msg.Query))
+		a.list.width, a.list.height, a.list.search.Width = w, h, sw
+		a.loader = SearchLoader(a.apiClient, msg.Query, searchHits)
 		a.view = ViewList
-		return a, nil
+		return a, a.reload()
+
+	case BackMsg:
+		return a, a.goBack()
+
+	case pollTick:
+		if cmd := a.poll(); cmd != nil {
+			return a, cmd
+		}
+		return a, a.tick()
+
+	case streamed:
+		if !msg.ok {
+			// Only closed when going offline or exiting; fall back to
+			// checking every interval.
+			a.updates = nil
+			return a, a.tick()
+		}
+		for _, id := range msg.items {
+			if !slices.Contains(a.pending, id) {
+				a.pending = append(a.pending, id)
+			}
+		}
+		return a, tea.Batch(a.next(), a.poll())
+
+	case Updated:
+		a.polling = false
+		if msg.MaxItem > 0 {
+			a.maxItem = msg.MaxItem
+		}
+		// A check may fail having fetched some of what changed, so what it
+		// did fetch is applied regardless. Results are matched by ID, so
+		// they are harmless if the user has moved on.
+		switch a.view {
+		case ViewList:
+			a.list, _ = a.list.Update(msg)
+		case ViewComments:
+			a.comments, _ = a.comments.Update(msg)
+		}
+		checked := a.checking
+		a.checking = nil
+		if msg.Err != nil {
+			// Tried again next time: at the next interval, or when more
+			// is streamed.
+			for _, id := range checked {
+				if !slices.Contains(a.pending, id) {
+					a.pending = append(a.pending, id)
+				}
+			}
+			return a, a.tick()
+		}
+		if a.updates != nil {
+			return a, a.poll() // anything streamed meanwhile
+		}
+		return a, a.tick()
 
 	case tea.WindowSizeMsg:
-		m1, _ := a.list.Update(msg)
-		a.list = m1
-		m2, _ := a.comments.Update(msg)
-		a.comments = m2
-		m3, _ := a.user.Update(msg)
-		a.user = m3
+		a.size = msg
+		inner := a.innerSize()
+		a.resize(inner)
+		resizeAll(a.back, inner)
+		resizeAll(a.forward, inner)
+		for i := range a.tabs {
+			if i != a.active && a.tabs[i].started {
+				a.tabs[i].screen.resize(inner)
+				resizeAll(a.tabs[i].back, inner)
+				resizeAll(a.tabs[i].forward, inner)
+			}
+		}
 		return a, nil
 	}
 
@@ -202,22 +401,12 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, cmd
 		}
 	case ViewComments:
-		if msg, ok := msg.(tea.KeyMsg); ok {
-			if msg.String() == "q" {
-				return a, tea.Quit
-			}
-		}
 		m, cmd := a.comments.Update(msg)
 		a.comments = m
 		if cmd != nil {
 			return a, cmd
 		}
 	case ViewUser:
-		if msg, ok := msg.(tea.KeyMsg); ok {
-			if msg.String() == "q" {
-				return a, tea.Quit
-			}
-		}
 		m, cmd := a.user.Update(msg)
 		a.user = m
 		if cmd != nil {
@@ -228,71 +417,94 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// searching reports whether the search prompt has focus.
+func (a *App) searching() bool { return a.view == ViewList && a.list.searching }
+
 func (a *App) View() string {
+	if a.showHelp {
+		return renderHelp(a.view, a.size.Width, a.size.Height)
+	}
+	bar := ""
+	if len(a.tabs) > 0 {
+		bar = a.tabBar() + "\n"
+	}
+	crumbs := a.breadcrumb()
 	switch a.view {
 	case ViewComments:
-		return a.comments.View()
+		m := a.comments
+		m.crumbs = crumbs
+		return bar + m.View()
 	case ViewUser:
-		return a.user.View()
+		m := a.user
+		m.crumbs = crumbs
+		return bar + m.View()
 	default:
-		return a.list.View()
+		m := a.list
+		m.crumbs = crumbs
+		m.back = len(a.back) > 0
+		m.tabbed = len(a.tabs) > 0
+		return bar + m.View()
 	}
 }
 
-// Run starts the bubbletea program with the given loader.
-func Run(client *api.Client, title string, loader func() ([]*api.Item, error)) error {
-	app := NewApp(client, title, loader)
-	p := tea.NewProgram(app, tea.WithAltScreen())
+// run starts the bubbletea program for app, sending it the result of the
+// initial load (if any) once it is ready.
+func run(app *App, initial tea.Cmd, opts []Option) error {
+	for _, opt := range opts {
+		opt(app)
+	}
+	if app.interval > 0 && app.stream && !app.apiClient.Offline() {
+		ctx, stop := context.WithCancel(app.ctx)
+		defer stop()
+		app.updates = app.apiClient.WatchUpdatesContext(ctx)
+	}
+	p := tea.NewProgram(app, tea.WithAltScreen(), tea.WithContext(app.ctx))
+	if initial != nil {
+		go func() { p.Send(initial()) }()
+	}
 	_, err := p.Run()
+	app.cancelLoad()
+	if errors.Is(err, tea.ErrProgramKilled) && app.ctx.Err() != nil {
+		return app.ctx.Err()
+	}
 	return err
 }
 
-// RunWithItems starts the TUI with a pre-built item list (e.g. search results).
-func RunWithItems(client *api.Client, title string, items []*api.Item) error {
-	app := NewApp(client, title, nil)
-	app.list.loading = false
-	app.list.items = items
-	p := tea.NewProgram(app, tea.WithAltScreen())
-	go func() { p.Send(StoriesLoaded{Items: items}) }()
-	_, err := p.Run()
-	return err
+// Run starts the bubbletea program with the given loader.
+func Run(ctx context.Context, client *api.Client, title string, loader Loader, opts ...Option) error {
+	return run(NewApp(ctx, client, title, loader), nil, opts)
 }
 
 // RunWithLoader starts the TUI loading items async.
-func RunWithLoader(client *api.Client, title string, loader func() ([]*api.Item, error)) error {
-	app := NewApp(client, title, loader)
-	p := tea.NewProgram(app, tea.WithAltScreen())
-	go func() { p.Send(LoadCmd(loader)()) }()
-	_, err := p.Run()
-	return err
+func RunWithLoader(ctx context.Context, client *api.Client, title string, loader Loader, opts ...Option) error {
+	app := NewApp(ctx, client, title, loader)
+	return run(app, app.reload(), opts)
 }
 
 // RunItem opens a single item's comment view directly.
-func RunItem(client *api.Client, id int) error {
+func RunItem(ctx context.Context, client *api.Client, id int, opts ...Option) error {
 	app := &App{
 		apiClient: client,
 		view:      ViewComments,
 		list:      NewListModel(fmt.Sprintf("Item #%d", id)),
 		comments:  NewCommentsModel(),
 		user:      NewUserModel(),
+		ctx:       ctx,
 	}
-	p := tea.NewProgram(app, tea.WithAltScreen())
-	go func() { p.Send(LoadItemCmd(client, id)()) }()
-	_, err := p.Run()
-	return err
+	app.comments.id = id
+	return run(app, app.reload(), opts)
 }
 
 // RunUser opens a user profile view directly.
-func RunUser(client *api.Client, username string) error {
+func RunUser(ctx context.Context, client *api.Client, username string, opts ...Option) error {
 	app := &App{
 		apiClient: client,
 		view:      ViewUser,
 		list:      NewListModel(""),
 		comments:  NewCommentsModel(),
 		user:      NewUserModel(),
+		ctx:       ctx,
 	}
-	p := tea.NewProgram(app, tea.WithAltScreen())
-	go func() { p.Send(LoadUserCmd(client, username)()) }()
-	_, err := p.Run()
-	return err
+	app.user.username = username
+	return run(app, app.reload(), opts)
 }
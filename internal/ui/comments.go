@@ -2,8 +2,10 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hexadecimoose/hncli/internal/api"
@@ -13,7 +15,8 @@ import (
 // ItemLoaded is sent when a single item (and its comment tree) is ready.
 type ItemLoaded struct {
 	Story    *api.Item
-	Comments []*api.Item
+	Comments []*api.Comment
+	Options  []*api.Item // a poll's options
 	Err      error
 }
 
@@ -22,21 +25,32 @@ type BackMsg struct{}
 
 // flatComment is a comment flattened with its indent level.
 type flatComment struct {
-	item   *api.Item
-	depth  int
-	hidden bool // collapsed
+	item        *api.Item
+	depth       int
+	parent      int  // index of the parent comment in the flat list, -1 for top-level
+	descendants int  // number of comments in this comment's subtree
+	hidden      bool // collapsed
 }
 
 // CommentsModel is a bubbletea model for a threaded comment view.
 type CommentsModel struct {
 	story   *api.Item
+	options []*api.Item // a poll's options, shown under the story
+	tree    []*api.Comment
+	opened  api.Item     // the story as first loaded, to mark changes against
+	fresh   map[int]bool // IDs of comments auto-refresh added
 	flat    []flatComment
+	cursor  int      // index into m.flat of the selected comment
 	lines   []string // all content lines, pre-rendered (excluding fixed header/footer)
+	starts  []int    // first line in m.lines of each flat comment, -1 if collapsed away
 	scroll  int      // first visible line index into m.lines
 	height  int
 	width   int
 	loading bool
 	err     error
+	id      int    // ID of the story shown, for reloading
+	focus   int    // ID of a comment to select once loaded, 0 for none
+	crumbs  string // breadcrumb shown before the title
 }
 
 // NewCommentsModel returns a loading comments model.
@@ -46,6 +60,22 @@ func NewCommentsModel() CommentsModel {
 
 func (m CommentsModel) Init() tea.Cmd { return nil }
 
+// poll renders a poll's options as a bar chart of their share of the votes.
+func (m *CommentsModel) poll() string {
+	total := api.Votes(m.options)
+	width := max(10, min(40, m.width-24))
+	var lines []string
+	for _, o := range m.options {
+		lines = append(lines, wrapText(util.StripHTML(o.Text), m.width-4, "  "))
+		lines = append(lines, fmt.Sprintf("  %s %s",
+			ScoreStyle.Render(util.Bar(o.Score, total, width)),
+			MetaStyle.Render(fmt.Sprintf("%d votes · %d%%", o.Score, util.Percent(o.Score, total))),
+		))
+	}
+	lines = append(lines, MetaStyle.Render(fmt.Sprintf("  %d votes in total", total)))
+	return strings.Join(lines, "\n")
+}
+
 // buildLines re-renders all scrollable content into m.lines.
 func (m *CommentsModel) buildLines() {
 	var lines []string
@@ -57,10 +87,12 @@ func (m *CommentsModel) buildLines() {
 
 	if m.story != nil {
 		// Story meta.
-		meta := fmt.Sprintf("  %s  ▲ %d  %s comments  by %s  %s",
+		meta := fmt.Sprintf("  %s  ▲ %d%s  %s%s comments  by %s  %s",
 			URLStyle.Render(m.story.URL),
 			m.story.Score,
+			delta(m.story.Score-m.opened.Score),
 			commentsStr(m.story.Descendants),
+			delta(m.story.Descendants-m.opened.Descendants),
 			CommentAuthorStyle.Render(m.story.By),
 			MetaStyle.Render(m.story.Age()),
 		)
@@ -69,34 +101,67 @@ func (m *CommentsModel) buildLines() {
 			add("")
 			add(wrapText(util.StripHTML(m.story.Text), m.width-4, "  "))
 		}
+		if len(m.options) > 0 {
+			add("")
+			add(m.poll())
+		}
 		add("")
 	}
 
-	for _, fc := range m.flat {
-		if fc.item == nil || fc.item.Deleted || fc.item.Dead {
-			continue
-		}
+	m.starts = make([]int, len(m.flat))
+	for i := 0; i < len(m.flat); i++ {
+		m.starts[i] = len(lines)
+		fc := m.flat[i]
 		indent := strings.Repeat("  ", fc.depth)
-		renderedBar := IndentStyle.Render("│ ")
+		barStyle := lipgloss.NewStyle().Foreground(indentColor(fc.depth))
+		if i == m.cursor {
+			barStyle = SelectedBarStyle
+		}
+		renderedBar := barStyle.Render("│ ")
 		displayPrefix := indent + renderedBar + "  "
 		// Use a plain-text prefix for width measurement — ANSI escapes in
 		// renderedBar would inflate len() and cause premature line wraps.
 		plainPrefixLen := len(indent) + len("│   ") // "│ " + "  " = 4 visible chars
 
-		// Comment header line — always the first line of a comment.
-		author := CommentAuthorStyle.Render(fc.item.By)
-		age := CommentTimeStyle.Render(fc.item.Age())
-		add(indent + renderedBar + author + "  " + age)
-
-		// Comment body: wrap to plain lines then prepend the rendered prefix.
-		text := util.StripHTML(fc.item.Text)
-		wrapWidth := m.width - plainPrefixLen
-		for _, paragraph := range strings.Split(text, "\n") {
-			for _, wline := range wrapToLines(paragraph, wrapWidth) {
-				lines = append(lines, displayPrefix+wline)
+		// A collapsed comment renders only its header; skip past its subtree.
+		var marker string
+		if fc.hidden {
+			marker = "  " + CollapsedStyle.Render(fmt.Sprintf("[+%d hidden]", fc.descendants))
+			for j := i + 1; j <= i+fc.descendants; j++ {
+				m.starts[j] = -1
 			}
 		}
-		add("") // blank separator between comments
+
+		// Removed comments are kept only as placeholders for their replies.
+		if placeholder := removedLabel(fc.item); placeholder != "" {
+			add(indent + renderedBar + MetaStyle.Render(placeholder) + marker)
+			add("")
+		} else {
+			// Comment header line — always the first line of a comment.
+			author := CommentAuthorStyle.Render(fc.item.By)
+			age := CommentTimeStyle.Render(fc.item.Age())
+			if m.fresh[fc.item.ID] {
+				age += "  " + ChangeStyle.Render("new")
+			}
+			add(indent + renderedBar + author + "  " + age + marker)
+
+			if !fc.hidden {
+				// Comment body: wrap to plain lines then prepend the rendered prefix.
+				// Deeply nested replies still get a usable column to wrap into.
+				text := util.StripHTML(fc.item.Text)
+				wrapWidth := max(m.width-plainPrefixLen, minWrapWidth)
+				for _, paragraph := range strings.Split(text, "\n") {
+					for _, wline := range wrapToLines(paragraph, wrapWidth) {
+						lines = append(lines, displayPrefix+wline)
+					}
+				}
+			}
+			add("") // blank separator between comments
+		}
+
+		if fc.hidden {
+			i += fc.descendants
+		}
 	}
 
 	m.lines = lines
@@ -108,44 +173,317 @@ func (m CommentsModel) Update(msg tea.Msg) (CommentsModel, tea.Cmd) {
 		m.loading = false
 		m.err = msg.Err
 		m.story = msg.Story
-		m.flat = flattenComments(msg.Comments, 0)
+		m.options = msg.Options
+		m.tree = msg.Comments
+		m.fresh = nil
+		if m.story != nil {
+			m.opened = *m.story
+		}
+		m.flat = flattenComments(msg.Comments, -1, nil)
+		m.cursor = 0
 		m.scroll = 0
 		m.buildLines()
+		for i, fc := range m.flat {
+			if fc.item.ID == m.focus {
+				m.moveTo(i)
+				break
+			}
+		}
+
+	case Updated:
+		m.update(msg)
 
 	case tea.WindowSizeMsg:
 		m.height = msg.Height - 2 // 1 fixed header + 1 fixed footer
 		m.width = msg.Width
 		m.buildLines()
+		m.scrollToCursor()
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "up", "k":
-			if m.scroll > 0 {
-				m.scroll--
+		switch {
+		case key.Matches(msg, keymap.Up):
+			m.moveTo(m.prevComment())
+		case key.Matches(msg, keymap.Down):
+			m.moveTo(m.nextComment())
+		case key.Matches(msg, keymap.PrevSibling):
+			m.moveTo(m.prevSibling())
+		case key.Matches(msg, keymap.NextSibling):
+			m.moveTo(m.nextSibling())
+		case key.Matches(msg, keymap.Parent):
+			if len(m.flat) > 0 {
+				m.moveTo(m.flat[m.cursor].parent)
 			}
-		case "down", "j":
-			if m.scroll < len(m.lines)-m.height {
-				m.scroll++
+		case key.Matches(msg, keymap.PrevThread):
+			m.moveTo(m.prevTopLevel())
+		case key.Matches(msg, keymap.NextThread):
+			m.moveTo(m.nextTopLevel())
+		case key.Matches(msg, keymap.Collapse):
+			if len(m.flat) > 0 && m.flat[m.cursor].descendants > 0 {
+				m.flat[m.cursor].hidden = !m.flat[m.cursor].hidden
+				m.buildLines()
+				m.scrollToCursor()
 			}
-		case "g":
+		case key.Matches(msg, keymap.PageUp):
+			m.scroll = max(0, m.scroll-m.height/2)
+		case key.Matches(msg, keymap.PageDown):
+			m.scroll = max(0, min(len(m.lines)-m.height, m.scroll+m.height/2))
+		case key.Matches(msg, keymap.Top):
+			m.moveTo(0)
 			m.scroll = 0
-		case "G":
+		case key.Matches(msg, keymap.Bottom):
+			m.moveTo(m.lastComment())
 			m.scroll = max(0, len(m.lines)-m.height)
-		case "o":
+		case key.Matches(msg, keymap.OpenURL):
 			if m.story != nil && m.story.URL != "" {
 				util.OpenBrowser(m.story.URL) //nolint:errcheck
 			}
-		case "c":
+		case key.Matches(msg, keymap.OpenHN):
 			if m.story != nil {
 				util.OpenBrowser(fmt.Sprintf("https://news.ycombinator.com/item?id=%d", m.story.ID)) //nolint:errcheck
 			}
-		case "q", "backspace", "esc", "left", "h":
+		case key.Matches(msg, keymap.OpenCommentHN):
+			if item := m.selected(); item != nil {
+				util.OpenBrowser(fmt.Sprintf("https://news.ycombinator.com/item?id=%d", item.ID)) //nolint:errcheck
+			}
+		case key.Matches(msg, keymap.Author):
+			if item := m.selected(); item != nil && item.By != "" {
+				return m, func() tea.Msg { return OpenUser{Username: item.By} }
+			}
+		case key.Matches(msg, keymap.Submitter):
+			if m.story != nil && m.story.By != "" {
+				return m, func() tea.Msg { return OpenUser{Username: m.story.By} }
+			}
+		case key.Matches(msg, keymap.Back):
 			return m, func() tea.Msg { return BackMsg{} }
 		}
 	}
 	return m, nil
 }
 
+// update applies what auto-refresh found: fresh copies of the story and
+// comments that changed, and new replies, which are marked as such. The
+// selected comment and collapsed subtrees stay as they were.
+func (m *CommentsModel) update(msg Updated) {
+	if m.story == nil {
+		return
+	}
+	nodes := make(map[int]*api.Comment)
+	var walk func([]*api.Comment)
+	walk = func(cs []*api.Comment) {
+		for _, c := range cs {
+			nodes[c.ID] = c
+			walk(c.Replies)
+		}
+	}
+	walk(m.tree)
+
+	for _, item := range msg.Items {
+		if item.ID == m.story.ID {
+			m.story = item
+		} else if n := nodes[item.ID]; n != nil {
+			n.Item = item
+		}
+	}
+	if len(msg.Items) == 0 && len(msg.Replies) == 0 {
+		return
+	}
+	for parent, replies := range msg.Replies {
+		if len(replies) == 0 {
+			continue
+		}
+		if m.fresh == nil {
+			m.fresh = make(map[int]bool)
+		}
+		var mark func([]*api.Comment)
+		mark = func(cs []*api.Comment) {
+			for _, c := range cs {
+				m.fresh[c.ID] = true
+				mark(c.Replies)
+			}
+		}
+		mark(replies)
+		if parent == m.story.ID {
+			m.tree = splice(m.tree, m.story.Kids, replies)
+		} else if n := nodes[parent]; n != nil {
+			n.Replies = splice(n.Replies, n.Kids, replies)
+		}
+	}
+
+	selected, hidden := 0, make(map[int]bool)
+	if item := m.selected(); item != nil {
+		selected = item.ID
+	}
+	for _, fc := range m.flat {
+		if fc.hidden {
+			hidden[fc.item.ID] = true
+		}
+	}
+	m.flat = flattenComments(m.tree, -1, nil)
+	m.cursor = 0
+	for i := range m.flat {
+		id := m.flat[i].item.ID
+		m.flat[i].hidden = hidden[id]
+		if id == selected {
+			m.cursor = i
+		}
+	}
+	m.buildLines()
+	m.scrollToCursor()
+}
+
+// splice adds replies to nodes in the order the parent's kids list them.
+// Replies the kids don't list yet go last, as do nodes they no longer list.
+func splice(nodes []*api.Comment, kids []int, replies []*api.Comment) []*api.Comment {
+	byID := make(map[int]*api.Comment, len(nodes)+len(replies))
+	for _, n := range nodes {
+		byID[n.ID] = n
+	}
+	for _, r := range replies {
+		byID[r.ID] = r
+	}
+	out := make([]*api.Comment, 0, len(byID))
+	for _, id := range kids {
+		if n, ok := byID[id]; ok {
+			out = append(out, n)
+			delete(byID, id)
+		}
+	}
+	for _, n := range slices.Concat(nodes, replies) {
+		if _, ok := byID[n.ID]; ok {
+			out = append(out, n)
+			delete(byID, n.ID)
+		}
+	}
+	return out
+}
+
+// selected returns the selected comment, or nil if there are none.
+func (m CommentsModel) selected() *api.Item {
+	if len(m.flat) == 0 {
+		return nil
+	}
+	return m.flat[m.cursor].item
+}
+
+// moveTo selects the comment at flat index i, if valid, and scrolls it into view.
+func (m *CommentsModel) moveTo(i int) {
+	if i < 0 || i >= len(m.flat) || i == m.cursor {
+		return
+	}
+	m.cursor = i
+	m.buildLines()
+	m.scrollToCursor()
+}
+
+// scrollToCursor adjusts m.scroll so the selected comment is on screen,
+// showing as much of it as fits.
+func (m *CommentsModel) scrollToCursor() {
+	if len(m.flat) == 0 || m.starts[m.cursor] < 0 {
+		return
+	}
+	if m.cursor == 0 {
+		// Keep the story header in view while on the first comment.
+		m.scroll = 0
+		return
+	}
+	start := m.starts[m.cursor]
+	end := len(m.lines)
+	if next := m.nextComment(); next != m.cursor {
+		end = m.starts[next]
+	}
+	switch {
+	case start < m.scroll:
+		m.scroll = start
+	case end > m.scroll+m.height:
+		m.scroll = min(start, end-m.height)
+	}
+}
+
+// nextComment returns the next comment that is not inside a collapsed
+// subtree, or the cursor itself if there is none.
+func (m CommentsModel) nextComment() int {
+	if len(m.flat) == 0 {
+		return m.cursor
+	}
+	next := m.cursor + 1
+	if m.flat[m.cursor].hidden {
+		next += m.flat[m.cursor].descendants
+	}
+	if next >= len(m.flat) {
+		return m.cursor
+	}
+	return next
+}
+
+// prevComment returns the previous comment that is not inside a collapsed
+// subtree, or the cursor itself if there is none.
+func (m CommentsModel) prevComment() int {
+	for i := m.cursor - 1; i >= 0; i-- {
+		if m.starts[i] >= 0 {
+			return i
+		}
+	}
+	return m.cursor
+}
+
+// nextSibling returns the next comment sharing the cursor's parent, or -1.
+func (m CommentsModel) nextSibling() int {
+	if len(m.flat) == 0 {
+		return -1
+	}
+	fc := m.flat[m.cursor]
+	next := m.cursor + fc.descendants + 1
+	if next < len(m.flat) && m.flat[next].parent == fc.parent {
+		return next
+	}
+	return -1
+}
+
+// prevSibling returns the previous comment sharing the cursor's parent, or -1.
+func (m CommentsModel) prevSibling() int {
+	if len(m.flat) == 0 {
+		return -1
+	}
+	parent := m.flat[m.cursor].parent
+	for i := m.cursor - 1; i > parent; i-- {
+		if m.flat[i].parent == parent {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextTopLevel returns the next top-level comment after the cursor, or -1.
+func (m CommentsModel) nextTopLevel() int {
+	for i := m.cursor + 1; i < len(m.flat); i++ {
+		if m.flat[i].depth == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// prevTopLevel returns the top-level comment containing the cursor, or the
+// one before it if the cursor is already top-level.
+func (m CommentsModel) prevTopLevel() int {
+	for i := m.cursor - 1; i >= 0; i-- {
+		if m.flat[i].depth == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastComment returns the last comment that is not inside a collapsed subtree.
+func (m CommentsModel) lastComment() int {
+	for i := len(m.flat) - 1; i >= 0; i-- {
+		if m.starts[i] >= 0 {
+			return i
+		}
+	}
+	return 0
+}
+
 func (m CommentsModel) View() string {
 	var b strings.Builder
 
@@ -154,7 +492,7 @@ func (m CommentsModel) View() string {
 	if m.story != nil {
 		title = m.story.Title
 	}
-	b.WriteString(HeaderStyle.Width(m.width).Render("  " + title))
+	b.WriteString(HeaderStyle.Width(m.width).Render("  " + withCrumbs(m.crumbs, title)))
 	b.WriteString("\n")
 
 	if m.loading {
@@ -183,22 +521,43 @@ func (m CommentsModel) View() string {
 			pct = 100
 		}
 	}
-	b.WriteString(HelpStyle.Render(fmt.Sprintf(
-		"  ↑/↓ scroll · o: open url · c: open hn · r: refresh · ←/esc: back · q: quit  [%d%%]", pct,
-	)))
+	k := keymap
+	b.WriteString(footer(m.width, fmt.Sprintf("  [%d%%]", pct),
+		pair(k.Up, k.Down, "comment"), bind(k.Collapse, "collapse"), pair(k.PrevSibling, k.NextSibling, "sibling"),
+		bind(k.Parent, "parent"), bind(k.NextThread, "next thread"), bind(k.Author, "author"), bind(k.Submitter, "op"),
+		bind(k.OpenURL, "open url"), pair(k.OpenHN, k.OpenCommentHN, "story/comment on hn"), bind(k.Refresh, "refresh"),
+		bind(k.Back, "back"), bind(k.Help, "help"), bind(k.Quit, "quit")))
 	return b.String()
 }
 
-// flattenComments converts a tree of comments into a flat list with depth info.
-func flattenComments(comments []*api.Item, depth int) []flatComment {
-	var out []flatComment
+// removedLabel returns the placeholder shown for a deleted or dead comment,
+// or "" if the comment is live.
+func removedLabel(item *api.Item) string {
+	switch {
+	case item.Deleted:
+		return "[deleted]"
+	case item.Dead:
+		return "[dead]"
+	}
+	return ""
+}
+
+// minWrapWidth is the narrowest column comment text is wrapped to, however
+// deep the reply is nested.
+const minWrapWidth = 20
+
+// flattenComments appends a tree of comments to out as a depth-first flat
+// list, dropping deleted or dead comments that have no visible replies.
+// parent is the flat index of the comments' parent, or -1 at the top level.
+func flattenComments(comments []*api.Comment, parent int, out []flatComment) []flatComment {
 	for _, c := range comments {
-		if c == nil {
+		if c == nil || !c.Visible() {
 			continue
 		}
-		out = append(out, flatComment{item: c, depth: depth})
-		// Note: child comments are not yet fetched; they require separate API calls.
-		// For simplicity we don't recurse beyond first level in this version.
+		i := len(out)
+		out = append(out, flatComment{item: c.Item, depth: c.Depth, parent: parent})
+		out = flattenComments(c.Replies, i, out)
+		out[i].descendants = len(out) - i - 1
 	}
 	return out
 }
@@ -261,12 +620,5 @@ func wrapText(text string, width int, indent string) string {
 
 // indentColor maps depth to a lipgloss colour for variety (used when rendering nested threads).
 func indentColor(depth int) lipgloss.Color {
-	palette := []lipgloss.Color{
-		lipgloss.Color("#FF6600"),
-		lipgloss.Color("#E8C547"),
-		lipgloss.Color("#72C472"),
-		lipgloss.Color("#5BC8DB"),
-		lipgloss.Color("#A78BFA"),
-	}
-	return palette[depth%len(palette)]
+	return theme.Depth[depth%len(theme.Depth)]
 }
@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hexadecimoose/hncli/internal/api"
+)
+
+// comment returns a comment with replies, at depth.
+func comment(id, depth int, replies ...*api.Comment) *api.Comment {
+	kids := make([]int, 0, len(replies))
+	for _, r := range replies {
+		kids = append(kids, r.ID)
+	}
+	return &api.Comment{
+		Item:    &api.Item{ID: id, Type: "comment", By: "user", Text: "Some text.", Kids: kids},
+		Depth:   depth,
+		Replies: replies,
+	}
+}
+
+// testThread is, flattened:
+//
+//	0: 1
+//	1:   2
+//	2:     3
+//	3:   4
+//	4: 8
+//	5: 5
+//	6:   6
+//
+// with a dead comment 7, which has no replies, left out.
+func testThread() (*api.Item, []*api.Comment) {
+	dead := comment(7, 0)
+	dead.Dead = true
+	tree := []*api.Comment{
+		comment(1, 0, comment(2, 1, comment(3, 2)), comment(4, 1)),
+		dead,
+		comment(8, 0),
+		comment(5, 0, comment(6, 1)),
+	}
+	return &api.Item{ID: 100, Type: "story", Title: "Story", Kids: []int{1, 7, 8, 5}}, tree
+}
+
+// loadedThread returns a comments model showing testThread on a screen
+// height lines high.
+func loadedThread(height int) CommentsModel {
+	story, tree := testThread()
+	m := NewCommentsModel()
+	m, _ = m.Update(ItemLoaded{Story: story, Comments: tree})
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: height})
+	return m
+}
+
+// flatIDs returns the IDs of the flattened comments, in order.
+func flatIDs(m CommentsModel) []int {
+	var ids []int
+	for _, fc := range m.flat {
+		ids = append(ids, fc.item.ID)
+	}
+	return ids
+}
+
+func TestFlattenComments(t *testing.T) {
+	_, tree := testThread()
+	deleted := comment(9, 0, comment(10, 1))
+	deleted.Deleted = true
+	tree = append(tree, deleted)
+
+	flat := flattenComments(tree, -1, nil)
+	type want struct{ id, depth, parent, descendants int }
+	wants := []want{
+		{1, 0, -1, 3}, {2, 1, 0, 1}, {3, 2, 1, 0}, {4, 1, 0, 0}, {8, 0, -1, 0},
+		{5, 0, -1, 1}, {6, 1, 5, 0},
+		{9, 0, -1, 1}, {10, 1, 7, 0}, // deleted, but kept for its reply
+	}
+	if len(flat) != len(wants) {
+		t.Fatalf("flattened %d comments, want %d", len(flat), len(wants))
+	}
+	for i, w := range wants {
+		fc := flat[i]
+		if got := (want{fc.item.ID, fc.depth, fc.parent, fc.descendants}); got != w {
+			t.Errorf("flat[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestCommentNavigation(t *testing.T) {
+	tests := []struct {
+		cursor                   int
+		nextSibling, prevSibling int
+		nextTop, prevTop         int
+	}{
+		{0, 4, -1, 4, -1},
+		{1, 3, -1, 4, 0}, // past 2's reply
+		{2, -1, -1, 4, 0},
+		{3, -1, 1, 4, 0},
+		{4, 5, 0, 5, 0},
+		{5, -1, 4, -1, 4},
+		{6, -1, -1, -1, 5},
+	}
+	m := loadedThread(40)
+	for _, tt := range tests {
+		m.cursor = tt.cursor
+		if got := m.nextSibling(); got != tt.nextSibling {
+			t.Errorf("nextSibling from %d = %d, want %d", tt.cursor, got, tt.nextSibling)
+		}
+		if got := m.prevSibling(); got != tt.prevSibling {
+			t.Errorf("prevSibling from %d = %d, want %d", tt.cursor, got, tt.prevSibling)
+		}
+		if got := m.nextTopLevel(); got != tt.nextTop {
+			t.Errorf("nextTopLevel from %d = %d, want %d", tt.cursor, got, tt.nextTop)
+		}
+		if got := m.prevTopLevel(); got != tt.prevTop {
+			t.Errorf("prevTopLevel from %d = %d, want %d", tt.cursor, got, tt.prevTop)
+		}
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	var (
+		space  = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		down   = tea.KeyMsg{Type: tea.KeyDown}
+		up     = tea.KeyMsg{Type: tea.KeyUp}
+		bottom = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'G'}}
+		next   = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{']'}}
+	)
+	tests := []struct {
+		name   string
+		cursor int
+		keys   []tea.KeyMsg
+		want   int   // cursor afterwards
+		hidden []int // flat indexes collapsed away
+	}{
+		{"down skips a collapsed subtree", 0, []tea.KeyMsg{space, down}, 4, []int{1, 2, 3}},
+		{"up from below lands on the collapsed root", 0, []tea.KeyMsg{space, down, up}, 0, []int{1, 2, 3}},
+		{"nested collapse", 1, []tea.KeyMsg{space, down}, 3, []int{2}},
+		{"next sibling past a collapsed comment", 1, []tea.KeyMsg{space, next}, 3, []int{2}},
+		{"bottom stops outside a collapsed subtree", 5, []tea.KeyMsg{space, bottom}, 5, []int{6}},
+		{"expanding again", 0, []tea.KeyMsg{space, space, down}, 1, nil},
+		{"no replies to collapse", 4, []tea.KeyMsg{space, down}, 5, nil},
+	}
+	for _, tt := range tests {
+		m := loadedThread(40)
+		m.moveTo(tt.cursor)
+		for _, k := range tt.keys {
+			m, _ = m.Update(k)
+		}
+		if m.cursor != tt.want {
+			t.Errorf("%s: cursor = %d, want %d", tt.name, m.cursor, tt.want)
+		}
+		var hidden []int
+		for i, start := range m.starts {
+			if start < 0 {
+				hidden = append(hidden, i)
+			}
+		}
+		if !slices.Equal(hidden, tt.hidden) {
+			t.Errorf("%s: collapsed away %v, want %v", tt.name, hidden, tt.hidden)
+		}
+	}
+}
+
+func TestScrollToCursor(t *testing.T) {
+	m := loadedThread(10)
+	for i := range m.flat {
+		m.moveTo(i)
+		if start := m.starts[i]; start < m.scroll || start >= m.scroll+m.height {
+			t.Errorf("comment %d starts at line %d, off the screen of lines %d-%d", i, start, m.scroll, m.scroll+m.height-1)
+		}
+	}
+	for i := len(m.flat) - 1; i > 0; i-- {
+		m.moveTo(i)
+		if start := m.starts[i]; start < m.scroll || start >= m.scroll+m.height {
+			t.Errorf("going up, comment %d starts at line %d, off the screen of lines %d-%d", i, start, m.scroll, m.scroll+m.height-1)
+		}
+	}
+	m.moveTo(0)
+	if m.scroll != 0 {
+		t.Errorf("scroll on the first comment = %d, want 0 to show the story", m.scroll)
+	}
+}
+
+func TestUpdateSplicesReplies(t *testing.T) {
+	m := loadedThread(40)
+	m.moveTo(5) // comment 5
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m.moveTo(3) // comment 4
+
+	story, _ := testThread()
+	story.Kids = []int{11, 1, 8, 5}
+	parent := &api.Item{ID: 2, Type: "comment", By: "user", Kids: []int{9, 3}}
+	m, _ = m.Update(Updated{
+		Items: []*api.Item{story, parent},
+		Replies: map[int][]*api.Comment{
+			2:   {comment(9, 2)},
+			5:   {comment(12, 1)},
+			100: {comment(11, 0, comment(13, 1))},
+		},
+	})
+
+	if got, want := flatIDs(m), []int{11, 13, 1, 2, 9, 3, 4, 8, 5, 6, 12}; !slices.Equal(got, want) {
+		t.Fatalf("comments after update = %v, want %v", got, want)
+	}
+	if id := m.selected().ID; id != 4 {
+		t.Errorf("selected comment after update = %d, want 4 still", id)
+	}
+	for _, id := range []int{9, 11, 12, 13} {
+		if !m.fresh[id] {
+			t.Errorf("reply %d is not marked new", id)
+		}
+	}
+	if i := slices.Index(flatIDs(m), 5); !m.flat[i].hidden || m.flat[i].descendants != 2 {
+		t.Errorf("comment 5 after a reply = %+v, want it still collapsed, over both replies", m.flat[i])
+	}
+	if i := slices.Index(flatIDs(m), 12); m.starts[i] >= 0 {
+		t.Error("a new reply under a collapsed comment is shown")
+	}
+	if m.flat[4].parent != 3 || m.flat[4].depth != 2 {
+		t.Errorf("reply 9 = %+v, want it under comment 2", m.flat[4])
+	}
+}
@@ -0,0 +1,244 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/util"
+)
+
+// screen is an entry in the navigation history: a view together with the
+// models behind it, cursor and scroll position included, so that going
+// back shows it exactly as it was left. Only the model for view matters.
+type screen struct {
+	view     View
+	list     ListModel
+	loader   Loader
+	comments CommentsModel
+	user     UserModel
+}
+
+// maxCrumbs is how many previous screens the breadcrumb names, and
+// crumbWidth how much of each title it shows.
+const (
+	maxCrumbs  = 3
+	crumbWidth = 24
+)
+
+// current returns the active screen.
+func (a *App) current() screen {
+	return screen{view: a.view, list: a.list, loader: a.loader, comments: a.comments, user: a.user}
+}
+
+// set makes s the active screen.
+func (a *App) set(s screen) {
+	a.view, a.list, a.loader, a.comments, a.user = s.view, s.list, s.loader, s.comments, s.user
+}
+
+// stash returns the active screen for keeping in the history. Any page
+// the list was fetching is abandoned along with the load in flight.
+func (a *App) stash() screen {
+	s := a.current()
+	s.list.more.fetching = 0
+	s.user.more.fetching = 0
+	return s
+}
+
+// navigate records the active screen in the history before the caller
+// replaces it. Screens that had been backed out of are forgotten.
+func (a *App) navigate() {
+	a.cancelLoad()
+	a.back = append(a.back, a.stash())
+	a.forward = nil
+}
+
+// goBack returns to the previous screen, if any.
+func (a *App) goBack() tea.Cmd {
+	if len(a.back) == 0 {
+		return nil
+	}
+	a.forward = append(a.forward, a.stash())
+	s := a.back[len(a.back)-1]
+	a.back = a.back[:len(a.back)-1]
+	return a.restore(s)
+}
+
+// goForward returns to the screen most recently backed out of, if any.
+func (a *App) goForward() tea.Cmd {
+	if len(a.forward) == 0 {
+		return nil
+	}
+	a.back = append(a.back, a.stash())
+	s := a.forward[len(a.forward)-1]
+	a.forward = a.forward[:len(a.forward)-1]
+	return a.restore(s)
+}
+
+// restore makes s the active screen. A screen left before it finished
+// loading had its load cancelled, so the load is started again.
+func (a *App) restore(s screen) tea.Cmd {
+	a.cancelLoad()
+	a.set(s)
+	return a.resume()
+}
+
+// resume starts again whatever the active screen was loading when it was
+// left: all of it, or the page of a list or profile it was fetching.
+func (a *App) resume() tea.Cmd {
+	if a.loading() {
+		return a.reload()
+	}
+	var cmd tea.Cmd
+	switch a.view {
+	case ViewList:
+		a.list, cmd = a.list.loadMore()
+	case ViewUser:
+		a.user, cmd = a.user.loadMore()
+	}
+	return cmd
+}
+
+// loading reports whether the active screen is waiting for data.
+func (a *App) loading() bool {
+	switch a.view {
+	case ViewComments:
+		return a.comments.loading
+	case ViewUser:
+		return a.user.loading
+	default:
+		return a.list.loading
+	}
+}
+
+// reload starts loading the active screen's data.
+func (a *App) reload() tea.Cmd { return a.load(false) }
+
+// load starts loading the active screen's data, skipping the cache if
+// fresh is set.
+func (a *App) load(fresh bool) tea.Cmd {
+	start := func() context.Context {
+		ctx := a.startLoad()
+		if fresh {
+			ctx = api.Fresh(ctx)
+		}
+		return ctx
+	}
+	switch a.view {
+	case ViewComments:
+		if a.comments.id == 0 {
+			return nil
+		}
+		return a.track(LoadItemCmd(start(), a.apiClient, a.comments.id))
+	case ViewUser:
+		if a.user.username == "" {
+			return nil
+		}
+		return a.track(LoadUserCmd(start(), a.apiClient, a.user.username))
+	default:
+		if a.loader == nil {
+			return nil
+		}
+		return a.track(LoadCmd(start(), a.apiClient, a.loader))
+	}
+}
+
+// refresh empties the active screen and loads it again, keeping the
+// selected comment selected and a profile on the same tab.
+func (a *App) refresh() tea.Cmd {
+	switch a.view {
+	case ViewComments:
+		if a.comments.id == 0 {
+			return nil
+		}
+		m := NewCommentsModel()
+		m.width, m.height = a.comments.width, a.comments.height
+		m.id = a.comments.id
+		if len(a.comments.flat) > 0 {
+			m.focus = a.comments.flat[a.comments.cursor].item.ID
+		}
+		a.comments = m
+	case ViewUser:
+		if a.user.username == "" {
+			return nil
+		}
+		m := NewUserModel()
+		m.width, m.height = a.user.width, a.user.height
+		m.username = a.user.username
+		m.tab = a.user.tab
+		a.user = m
+	default:
+		if a.loader == nil {
+			return nil
+		}
+		a.list.loading = true
+		a.list.items = nil
+		a.list.cursor = 0
+		a.list.offset = 0
+	}
+	// The user asked for the latest, so the cache is skipped.
+	return a.load(true)
+}
+
+// resize passes a new terminal size to every model of the screen.
+func (s *screen) resize(msg tea.WindowSizeMsg) {
+	s.list, _ = s.list.Update(msg)
+	s.comments, _ = s.comments.Update(msg)
+	s.user, _ = s.user.Update(msg)
+}
+
+// resizeAll passes a new terminal size to every screen in screens.
+func resizeAll(screens []screen, msg tea.WindowSizeMsg) {
+	for i := range screens {
+		screens[i].resize(msg)
+	}
+}
+
+// resize passes a new terminal size to the active screen's models.
+func (a *App) resize(msg tea.WindowSizeMsg) {
+	s := a.current()
+	s.resize(msg)
+	a.set(s)
+}
+
+// title names the screen in the breadcrumb.
+func (s screen) title() string {
+	switch s.view {
+	case ViewComments:
+		if s.comments.story != nil && s.comments.story.Title != "" {
+			return s.comments.story.Title
+		}
+		return fmt.Sprintf("Item #%d", s.comments.id)
+	case ViewUser:
+		return s.user.username
+	default:
+		return s.list.title
+	}
+}
+
+// breadcrumb names the screens that back leads to, e.g.
+// "Top Stories › Show HN: …", or "" at the start of the history.
+func (a *App) breadcrumb() string {
+	if len(a.back) == 0 {
+		return ""
+	}
+	var parts []string
+	start := max(0, len(a.back)-maxCrumbs)
+	if start > 0 {
+		parts = append(parts, "…")
+	}
+	for _, s := range a.back[start:] {
+		parts = append(parts, util.Truncate(s.title(), crumbWidth))
+	}
+	return strings.Join(parts, " › ")
+}
+
+// withCrumbs prefixes a screen title with the breadcrumb, if there is one.
+func withCrumbs(crumbs, title string) string {
+	if crumbs == "" {
+		return title
+	}
+	return crumbs + " › " + title
+}
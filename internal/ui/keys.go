@@ -0,0 +1,509 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// KeyMap holds the key bindings of the TUI. Screens share the bindings for
+// what they have in common, such as moving up and down.
+type KeyMap struct {
+	// Everywhere.
+	Quit    key.Binding
+	Help    key.Binding
+	Refresh key.Binding
+	Back    key.Binding
+	Forward key.Binding
+	NextTab key.Binding // next feed tab, or next activity tab on a profile
+	PrevTab key.Binding
+	GoToTab key.Binding
+
+	// Moving through lists and threads.
+	Up       key.Binding
+	Down     key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+
+	// Acting on what is selected.
+	Open    key.Binding
+	OpenURL key.Binding
+	OpenHN  key.Binding
+	Author  key.Binding
+
+	// The search prompt.
+	Search key.Binding
+	Submit key.Binding
+	Cancel key.Binding
+
+	// Comment threads.
+	Collapse      key.Binding
+	Parent        key.Binding
+	PrevSibling   key.Binding
+	NextSibling   key.Binding
+	PrevThread    key.Binding
+	NextThread    key.Binding
+	OpenCommentHN key.Binding
+	Submitter     key.Binding
+}
+
+// action is a binding as named in the config file, with what it does.
+type action struct {
+	name    string
+	desc    string
+	binding func(*KeyMap) *key.Binding
+}
+
+var actions = []action{
+	{"quit", "quit", func(k *KeyMap) *key.Binding { return &k.Quit }},
+	{"help", "show all keys", func(k *KeyMap) *key.Binding { return &k.Help }},
+	{"refresh", "refresh", func(k *KeyMap) *key.Binding { return &k.Refresh }},
+	{"back", "back", func(k *KeyMap) *key.Binding { return &k.Back }},
+	{"forward", "forward", func(k *KeyMap) *key.Binding { return &k.Forward }},
+	{"next-tab", "next tab", func(k *KeyMap) *key.Binding { return &k.NextTab }},
+	{"prev-tab", "previous tab", func(k *KeyMap) *key.Binding { return &k.PrevTab }},
+	{"go-to-tab", "go to feed tab", func(k *KeyMap) *key.Binding { return &k.GoToTab }},
+	{"up", "up", func(k *KeyMap) *key.Binding { return &k.Up }},
+	{"down", "down", func(k *KeyMap) *key.Binding { return &k.Down }},
+	{"top", "first", func(k *KeyMap) *key.Binding { return &k.Top }},
+	{"bottom", "last", func(k *KeyMap) *key.Binding { return &k.Bottom }},
+	{"page-up", "scroll up half a page", func(k *KeyMap) *key.Binding { return &k.PageUp }},
+	{"page-down", "scroll down half a page", func(k *KeyMap) *key.Binding { return &k.PageDown }},
+	{"open", "open", func(k *KeyMap) *key.Binding { return &k.Open }},
+	{"open-url", "open url in browser", func(k *KeyMap) *key.Binding { return &k.OpenURL }},
+	{"open-hn", "open on hn", func(k *KeyMap) *key.Binding { return &k.OpenHN }},
+	{"author", "author's profile", func(k *KeyMap) *key.Binding { return &k.Author }},
+	{"search", "search", func(k *KeyMap) *key.Binding { return &k.Search }},
+	{"submit", "run search", func(k *KeyMap) *key.Binding { return &k.Submit }},
+	{"cancel", "cancel search", func(k *KeyMap) *key.Binding { return &k.Cancel }},
+	{"collapse", "collapse/expand replies", func(k *KeyMap) *key.Binding { return &k.Collapse }},
+	{"parent", "parent comment", func(k *KeyMap) *key.Binding { return &k.Parent }},
+	{"prev-sibling", "previous sibling", func(k *KeyMap) *key.Binding { return &k.PrevSibling }},
+	{"next-sibling", "next sibling", func(k *KeyMap) *key.Binding { return &k.NextSibling }},
+	{"prev-thread", "previous top-level comment", func(k *KeyMap) *key.Binding { return &k.PrevThread }},
+	{"next-thread", "next top-level comment", func(k *KeyMap) *key.Binding { return &k.NextThread }},
+	{"open-comment-hn", "open comment on hn", func(k *KeyMap) *key.Binding { return &k.OpenCommentHN }},
+	{"submitter", "submitter's profile", func(k *KeyMap) *key.Binding { return &k.Submitter }},
+}
+
+// defaultKeys are the keys of each action in the default keymap.
+var defaultKeys = map[string][]string{
+	"quit":            {"q", "ctrl+c"},
+	"help":            {"?"},
+	"refresh":         {"r"},
+	"back":            {"esc", "left", "backspace", "h"},
+	"forward":         {"right", "l"},
+	"next-tab":        {"tab"},
+	"prev-tab":        {"shift+tab"},
+	"go-to-tab":       {"1", "2", "3", "4", "5", "6", "7", "8", "9"},
+	"up":              {"up", "k"},
+	"down":            {"down", "j"},
+	"top":             {"g"},
+	"bottom":          {"G"},
+	"page-up":         {"ctrl+u", "pgup"},
+	"page-down":       {"ctrl+d", "pgdown"},
+	"open":            {"enter"},
+	"open-url":        {"o"},
+	"open-hn":         {"c"},
+	"author":          {"u"},
+	"search":          {"/"},
+	"submit":          {"enter"},
+	"cancel":          {"esc"},
+	"collapse":        {"space", "enter"},
+	"parent":          {"p"},
+	"prev-sibling":    {"["},
+	"next-sibling":    {"]"},
+	"prev-thread":     {"{"},
+	"next-thread":     {"}"},
+	"open-comment-hn": {"C"},
+	"submitter":       {"a"},
+}
+
+// keyPresets are the built-in keymaps, as changes to the default one.
+var keyPresets = map[string]map[string][]string{
+	"default": nil,
+	"vim": {
+		"top":          {"g g", "home"},
+		"bottom":       {"G", "end"},
+		"page-up":      {"ctrl+u", "ctrl+b", "pgup"},
+		"page-down":    {"ctrl+d", "ctrl+f", "pgdown"},
+		"prev-sibling": {"N"},
+		"next-sibling": {"n"},
+		"collapse":     {"z a", "space", "enter"},
+	},
+	"emacs": {
+		"back":      {"ctrl+b", "esc", "left"},
+		"forward":   {"ctrl+f", "right"},
+		"up":        {"ctrl+p", "up"},
+		"down":      {"ctrl+n", "down"},
+		"top":       {"alt+<", "home"},
+		"bottom":    {"alt+>", "end"},
+		"page-up":   {"alt+v", "pgup"},
+		"page-down": {"ctrl+v", "pgdown"},
+		"search":    {"ctrl+s", "/"},
+		"cancel":    {"ctrl+g", "esc"},
+	},
+}
+
+// appActions are the actions the App matches before a screen sees a key.
+var appActions = []string{"quit", "help", "refresh", "forward", "next-tab", "prev-tab", "go-to-tab"}
+
+// screenActions are the actions each screen matches.
+var screenActions = map[View][]string{
+	ViewList: {"back", "up", "down", "top", "bottom", "open", "open-url", "open-hn", "author", "search"},
+	ViewComments: {"back", "up", "down", "top", "bottom", "page-up", "page-down", "collapse", "parent",
+		"prev-sibling", "next-sibling", "prev-thread", "next-thread", "open-url", "open-hn", "open-comment-hn",
+		"author", "submitter"},
+	ViewUser: {"back", "up", "down", "open", "open-url", "open-hn"},
+}
+
+// keyScopes are the sets of actions whose keys are matched together: the
+// App's own along with each screen's, and the search prompt's. No key may
+// be bound to two actions in the same scope.
+var keyScopes = [][]string{
+	slices.Concat(appActions, screenActions[ViewList]),
+	slices.Concat(appActions, screenActions[ViewComments]),
+	slices.Concat(appActions, screenActions[ViewUser]),
+	{"submit", "cancel"},
+}
+
+// DefaultKeyMap is the name of the keymap used unless SetKeyMap is called.
+const DefaultKeyMap = "default"
+
+// keymap holds the bindings in use.
+var keymap KeyMap
+
+func init() {
+	km, _ := NewKeyMap(DefaultKeyMap, nil)
+	SetKeyMap(km)
+}
+
+// SetKeyMap makes the TUI use km. It must be called before the TUI is
+// started.
+func SetKeyMap(km KeyMap) { keymap = km }
+
+// KeyMapNames returns the names of the built-in keymaps, sorted.
+func KeyMapNames() []string { return slices.Sorted(maps.Keys(keyPresets)) }
+
+// KeyActions returns the names of the actions keys can be bound to, as
+// used in the config file.
+func KeyActions() []string {
+	var names []string
+	for _, a := range actions {
+		names = append(names, a.name)
+	}
+	return names
+}
+
+// PresetKeys returns the keys bound to each action by the named keymap.
+func PresetKeys(name string) (map[string][]string, error) {
+	changes, ok := keyPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown keymap %q (want %s)", name, strings.Join(KeyMapNames(), ", "))
+	}
+	keys := maps.Clone(defaultKeys)
+	maps.Copy(keys, changes)
+	return keys, nil
+}
+
+// NewKeyMap returns the named built-in keymap with the actions in
+// overrides bound to the keys given instead. An action bound to no keys is
+// turned off.
+func NewKeyMap(name string, overrides map[string][]string) (KeyMap, error) {
+	keys, err := PresetKeys(name)
+	if err != nil {
+		return KeyMap{}, err
+	}
+	for a, k := range overrides {
+		if _, ok := defaultKeys[a]; !ok {
+			return KeyMap{}, fmt.Errorf("unknown key action %q (want one of %s)", a, strings.Join(KeyActions(), ", "))
+		}
+		for _, k := range k {
+			if _, _, ok := keySeq(k); !ok && strings.Contains(k, " ") && k != " " {
+				return KeyMap{}, fmt.Errorf("invalid key %q for %s (a sequence is two keys, such as \"g g\")", k, a)
+			}
+		}
+		keys[a] = k
+	}
+	if err := checkKeys(keys); err != nil {
+		return KeyMap{}, err
+	}
+	var km KeyMap
+	for _, a := range actions {
+		ks := keys[a.name]
+		b := key.NewBinding(key.WithKeys(keyNames(ks)...), key.WithHelp(keyHelp(ks), a.desc))
+		if len(ks) == 0 {
+			b.SetEnabled(false)
+		}
+		*a.binding(&km) = b
+	}
+	return km, nil
+}
+
+// checkKeys reports a key bound to two actions that are in play at once,
+// or to anything but quit if it is ctrl+c, which always quits. A key that
+// starts a sequence can't be bound by itself.
+func checkKeys(keys map[string][]string) error {
+	for _, scope := range keyScopes {
+		bound := make(map[string]string)  // key → action
+		starts := make(map[string]string) // first key of a sequence → the sequence
+		for _, a := range scope {
+			for _, k := range keyNames(keys[a]) {
+				if k == "ctrl+c" && a != "quit" {
+					return fmt.Errorf("ctrl+c always quits, so it can't be bound to %s", a)
+				}
+				first, _, isSeq := keySeq(k)
+				switch {
+				case isSeq && bound[first] != "":
+					return fmt.Errorf("key %q starts %q, so it can't be bound to %s", first, k, bound[first])
+				case isSeq:
+					starts[first] = k
+				case starts[k] != "":
+					return fmt.Errorf("key %q starts %q, so it can't be bound to %s", keyHelp([]string{k}), starts[k], a)
+				}
+				if other, ok := bound[k]; ok && other != a {
+					return fmt.Errorf("key %q is bound to both %s and %s", keyHelp([]string{k}), other, a)
+				}
+				bound[k] = a
+			}
+		}
+	}
+	return nil
+}
+
+// keySeq splits a sequence of two keys, such as "g g", into its keys.
+// ok is false for a single key.
+func keySeq(k string) (first, second string, ok bool) {
+	first, second, ok = strings.Cut(k, " ")
+	return first, second, ok && first != "" && second != "" && first != "space" && second != "space" &&
+		!strings.Contains(second, " ")
+}
+
+// keyNames converts keys as written in the config file to the names
+// bubbletea gives key presses. Only "space" differs; a sequence keeps the
+// names of its keys, separated by a space.
+func keyNames(keys []string) []string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		if k == "space" {
+			k = " "
+		}
+		names[i] = k
+	}
+	return names
+}
+
+// startsSeq reports whether k is the first key of a sequence bound on
+// view in the keymap in use.
+func startsSeq(view View, k string) bool {
+	for _, a := range actions {
+		if !slices.Contains(appActions, a.name) && !slices.Contains(screenActions[view], a.name) {
+			continue
+		}
+		for _, bk := range a.binding(&keymap).Keys() {
+			if first, _, ok := keySeq(bk); ok && first == k {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// seqMsg returns the key press that stands for the sequence of first then
+// msg, and whether the sequence is bound. Its String() is the sequence's
+// name, so key.Matches finds the binding.
+func seqMsg(first string, msg tea.KeyMsg) (tea.KeyMsg, bool) {
+	k := first + " " + msg.String()
+	if _, _, ok := keySeq(k); !ok {
+		return msg, false
+	}
+	for _, a := range actions {
+		if slices.Contains(a.binding(&keymap).Keys(), k) {
+			return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}, true
+		}
+	}
+	return msg, false
+}
+
+// keySymbols are shown in help instead of the names of some keys.
+var keySymbols = map[string]string{"up": "↑", "down": "↓", "left": "←", "right": "→", " ": "space"}
+
+// keyHelp describes keys for help, e.g. "↑/k", "gg" for a sequence, or
+// "1-9" for a run.
+func keyHelp(keys []string) string {
+	if isRun(keys) {
+		return keys[0] + "-" + keys[len(keys)-1]
+	}
+	symbol := func(k string) string {
+		if s, ok := keySymbols[k]; ok {
+			return s
+		}
+		return k
+	}
+	var ks []string
+	for _, k := range keys {
+		if first, second, ok := keySeq(k); ok {
+			k = symbol(first) + symbol(second)
+		} else {
+			k = symbol(k)
+		}
+		ks = append(ks, k)
+	}
+	return strings.Join(ks, "/")
+}
+
+// isRun reports whether keys are a run of single characters, such as the
+// digits 1 to 9, which help shows as a range.
+func isRun(keys []string) bool {
+	if len(keys) <= 3 {
+		return false
+	}
+	for i, k := range keys {
+		if len(k) != 1 || i > 0 && k[0] != keys[i-1][0]+1 {
+			return false
+		}
+	}
+	return true
+}
+
+// firstKey describes b for the footer by its first key, e.g. "↑", or by
+// its range if it is bound to a run, e.g. "1-9".
+func firstKey(b key.Binding) string {
+	if isRun(b.Keys()) {
+		return keyHelp(b.Keys())
+	}
+	return keyHelp(b.Keys()[:1])
+}
+
+// hint is one entry of a help footer.
+type hint struct {
+	key  string
+	desc string
+}
+
+// bind returns the footer hint for b, which the footer skips if b is off.
+func bind(b key.Binding, desc string) hint {
+	if !b.Enabled() {
+		return hint{}
+	}
+	return hint{firstKey(b), desc}
+}
+
+// pair returns a footer hint for two bindings that go together, e.g.
+// "↑/↓: navigate".
+func pair(a, b key.Binding, desc string) hint {
+	switch {
+	case !a.Enabled():
+		return bind(b, desc)
+	case !b.Enabled():
+		return bind(a, desc)
+	}
+	return hint{firstKey(a) + "/" + firstKey(b), desc}
+}
+
+// footer renders hints as a help bar followed by suffix. Hints before
+// the last two are dropped, from the end, until the bar fits width, so
+// that help and quit always show.
+func footer(width int, suffix string, hints ...hint) string {
+	var parts []string
+	for _, h := range hints {
+		if h.key != "" {
+			parts = append(parts, h.key+": "+h.desc)
+		}
+	}
+	s := "  " + strings.Join(parts, " · ") + suffix
+	for len(parts) > 2 && lipgloss.Width(s) > width {
+		parts = slices.Delete(parts, len(parts)-3, len(parts)-2)
+		s = "  " + strings.Join(parts, " · ") + suffix
+	}
+	return HelpStyle.Render(s)
+}
+
+// helpEntry is a line of the full help: a binding and what it does on the
+// screen in question, or its own description if desc is "".
+type helpEntry struct {
+	b    key.Binding
+	desc string
+}
+
+// helpSections returns the full help for view, as titled columns: what
+// works everywhere and what works on that screen.
+func helpSections(view View) (titles []string, sections [][]helpEntry) {
+	k := keymap
+	everywhere := []helpEntry{{k.Up, ""}, {k.Down, ""}, {k.Back, ""}, {k.Forward, ""}, {k.Refresh, ""}}
+	if view != ViewUser { // a profile's tabs are its own
+		everywhere = append(everywhere, helpEntry{k.NextTab, "next feed"}, helpEntry{k.PrevTab, "previous feed"})
+	}
+	everywhere = append(everywhere, helpEntry{k.GoToTab, ""}, helpEntry{k.Help, ""}, helpEntry{k.Quit, ""})
+	switch view {
+	case ViewComments:
+		return []string{"Everywhere", "Comments"}, [][]helpEntry{everywhere, {
+			{k.Top, "first comment"}, {k.Bottom, "last comment"}, {k.PageUp, ""}, {k.PageDown, ""},
+			{k.Collapse, ""}, {k.Parent, ""}, {k.PrevSibling, ""}, {k.NextSibling, ""},
+			{k.PrevThread, ""}, {k.NextThread, ""},
+			{k.Author, "comment author's profile"}, {k.Submitter, ""},
+			{k.OpenURL, "open story url in browser"}, {k.OpenHN, "open story on hn"}, {k.OpenCommentHN, ""},
+		}}
+	case ViewUser:
+		return []string{"Everywhere", "Profile"}, [][]helpEntry{everywhere, {
+			{k.NextTab, "next activity tab"}, {k.PrevTab, "previous activity tab"},
+			{k.Open, "open comments"}, {k.OpenHN, "open submission on hn"}, {k.OpenURL, "open profile in browser"},
+		}}
+	default:
+		return []string{"Everywhere", "Stories"}, [][]helpEntry{everywhere, {
+			{k.Top, ""}, {k.Bottom, ""}, {k.Open, "open comments"}, {k.Author, "submitter's profile"},
+			{k.OpenURL, ""}, {k.OpenHN, ""}, {k.Search, ""}, {k.Submit, ""}, {k.Cancel, ""},
+		}}
+	}
+}
+
+// renderHelp renders the full help for view, in columns side by side.
+func renderHelp(view View, width, height int) string {
+	titles, sections := helpSections(view)
+	var cols []string
+	for i, entries := range sections {
+		if i > 0 {
+			cols = append(cols, "    ")
+		}
+		cols = append(cols, helpColumn(titles[i], entries))
+	}
+	var b strings.Builder
+	b.WriteString(HeaderStyle.Width(width).Render("  Keys"))
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, cols...))
+	b.WriteString("\n")
+	b.WriteString(HelpStyle.Render("  any key: close"))
+	return lipgloss.NewStyle().MaxWidth(width).MaxHeight(height).Render(b.String())
+}
+
+// helpColumn renders a titled column of keys and what they do, skipping
+// bindings that are turned off.
+func helpColumn(title string, entries []helpEntry) string {
+	w := 0
+	for _, e := range entries {
+		if e.b.Enabled() {
+			w = max(w, lipgloss.Width(e.b.Help().Key))
+		}
+	}
+	var b strings.Builder
+	b.WriteString("  " + SelectedTitleStyle.Render(title) + "\n\n")
+	for _, e := range entries {
+		if !e.b.Enabled() {
+			continue
+		}
+		desc := e.desc
+		if desc == "" {
+			desc = e.b.Help().Desc
+		}
+		pad := strings.Repeat(" ", w-lipgloss.Width(e.b.Help().Key))
+		b.WriteString("  " + ScoreStyle.Render(e.b.Help().Key) + pad + "  " + MetaStyle.Render(desc) + "\n")
+	}
+	return b.String()
+}
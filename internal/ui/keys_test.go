@@ -0,0 +1,262 @@
+package ui
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPresetKeys(t *testing.T) {
+	for _, name := range KeyMapNames() {
+		keys, err := PresetKeys(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range KeyActions() {
+			if len(keys[a]) == 0 {
+				t.Errorf("%s keymap binds nothing to %s", name, a)
+			}
+		}
+		if _, err := NewKeyMap(name, nil); err != nil {
+			t.Errorf("NewKeyMap(%q): %v", name, err)
+		}
+	}
+
+	vim, _ := PresetKeys("vim")
+	if !slices.Equal(vim["page-down"], []string{"ctrl+d", "ctrl+f", "pgdown"}) || !slices.Equal(vim["up"], defaultKeys["up"]) {
+		t.Errorf("vim keymap page-down %v, up %v; want its own page-down and the default up", vim["page-down"], vim["up"])
+	}
+	vim["up"] = []string{"x"}
+	if defaultKeys["up"][0] != "up" {
+		t.Error("changing what PresetKeys returned changed the default keymap")
+	}
+
+	if _, err := PresetKeys("nano"); err == nil {
+		t.Error("PresetKeys accepted an unknown keymap")
+	}
+}
+
+func TestNewKeyMap(t *testing.T) {
+	km, err := NewKeyMap("emacs", map[string][]string{
+		"up":     {"w"},
+		"search": {"space", "/"},
+		"help":   {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	press := func(s string) tea.KeyMsg {
+		if s == " " {
+			return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+	if !key.Matches(press("w"), km.Up) || key.Matches(press("k"), km.Up) {
+		t.Errorf("up keys = %v, want only the override [w]", km.Up.Keys())
+	}
+	if !key.Matches(press(" "), km.Search) || km.Search.Help().Key != "space//" {
+		t.Errorf("search keys = %v, help %q; want space bound and shown by name", km.Search.Keys(), km.Search.Help().Key)
+	}
+	if km.Help.Enabled() {
+		t.Error("help is enabled with no keys")
+	}
+	if !slices.Equal(km.Down.Keys(), []string{"ctrl+n", "down"}) {
+		t.Errorf("down keys = %v, want the emacs keymap's", km.Down.Keys())
+	}
+
+	for _, tt := range []struct {
+		name      string
+		keymap    string
+		overrides map[string][]string
+	}{
+		{"unknown keymap", "nano", nil},
+		{"unknown action", "default", map[string][]string{"jump": {"x"}}},
+		{"app key taken by a screen", "default", map[string][]string{"refresh": {"j"}}},
+		{"two keys on one screen", "default", map[string][]string{"collapse": {"p"}}},
+		{"space by either name", "default", map[string][]string{"parent": {" "}}},
+		{"profile key taken by the app", "default", map[string][]string{"open": {"tab"}}},
+		{"prompt keys", "default", map[string][]string{"cancel": {"enter"}}},
+		{"ctrl+c", "default", map[string][]string{"back": {"ctrl+c"}}},
+		{"preset key taken", "emacs", map[string][]string{"refresh": {"ctrl+n"}}},
+	} {
+		if _, err := NewKeyMap(tt.keymap, tt.overrides); err == nil {
+			t.Errorf("%s: NewKeyMap(%q, %v) succeeded, want an error", tt.name, tt.keymap, tt.overrides)
+		}
+	}
+
+	// Keys may be shared by actions that are never in play at once.
+	for _, overrides := range []map[string][]string{
+		{"open": {"enter", "space"}},       // collapse, in threads
+		{"search": {"p"}},                  // parent, in threads
+		{"submit": {"j"}, "cancel": {"q"}}, // the prompt takes all keys
+		{"next-tab": {"]"}, "prev-tab": {"["}, "prev-sibling": {"("}, "next-sibling": {")"}},
+	} {
+		if _, err := NewKeyMap("default", overrides); err != nil {
+			t.Errorf("NewKeyMap(default, %v): %v", overrides, err)
+		}
+	}
+}
+
+func TestKeySequences(t *testing.T) {
+	km, err := NewKeyMap("vim", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := km.Top.Help().Key; h != "gg/home" {
+		t.Errorf("vim top help = %q, want gg/home", h)
+	}
+	if !slices.Equal(km.NextSibling.Keys(), []string{"n"}) {
+		t.Errorf("vim next-sibling keys = %v, want [n]", km.NextSibling.Keys())
+	}
+
+	for _, tt := range []struct {
+		keymap    string
+		overrides map[string][]string
+		ok        bool
+	}{
+		{"default", map[string][]string{"top": {"g g"}}, true},
+		{"default", map[string][]string{"top": {"g g"}, "bottom": {"g e"}}, true},
+		{"default", map[string][]string{"top": {"g g"}, "bottom": {"g"}}, false},
+		{"default", map[string][]string{"bottom": {"g"}, "top": {"g g"}}, false},
+		{"default", map[string][]string{"top": {"g g", "g"}}, false},
+		{"default", map[string][]string{"top": {"g g g"}}, false},
+		{"default", map[string][]string{"top": {"g space"}}, false},
+		{"vim", map[string][]string{"search": {"g"}}, false},
+		{"vim", map[string][]string{"parent": {"z"}}, false},
+		{"vim", map[string][]string{"author": {"z"}}, false},
+		{"vim", map[string][]string{"search": {"z"}}, true}, // z a is only for threads
+	} {
+		_, err := NewKeyMap(tt.keymap, tt.overrides)
+		if (err == nil) != tt.ok {
+			t.Errorf("NewKeyMap(%q, %v) = %v, want ok %v", tt.keymap, tt.overrides, err, tt.ok)
+		}
+	}
+}
+
+func TestKeyHelp(t *testing.T) {
+	tests := []struct {
+		keys []string
+		run  bool
+		help string
+	}{
+		{[]string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}, true, "1-9"},
+		{[]string{"a", "b", "c", "d"}, true, "a-d"},
+		{[]string{"1", "2", "3"}, false, "1/2/3"},
+		{[]string{"1", "2", "4", "5"}, false, "1/2/4/5"},
+		{[]string{"up", "k"}, false, "↑/k"},
+		{[]string{" ", "enter"}, false, "space/enter"},
+		{[]string{"f1", "f2", "f3", "f4"}, false, "f1/f2/f3/f4"},
+	}
+	for _, tt := range tests {
+		if got := isRun(tt.keys); got != tt.run {
+			t.Errorf("isRun(%q) = %v, want %v", tt.keys, got, tt.run)
+		}
+		if got := keyHelp(tt.keys); got != tt.help {
+			t.Errorf("keyHelp(%q) = %q, want %q", tt.keys, got, tt.help)
+		}
+	}
+}
+
+func TestFooter(t *testing.T) {
+	km, err := NewKeyMap("default", map[string][]string{"refresh": {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hints := []hint{
+		pair(km.Up, km.Down, "navigate"),
+		bind(km.Open, "comments"),
+		bind(km.Refresh, "refresh"),
+		bind(km.GoToTab, "feeds"),
+		bind(km.Help, "help"),
+		bind(km.Quit, "quit"),
+	}
+
+	got := footer(200, " · 30 stories", hints...)
+	want := "↑/↓: navigate · enter: comments · 1-9: feeds · ?: help · q: quit · 30 stories"
+	if !strings.Contains(got, want) {
+		t.Errorf("wide footer = %q, want it to contain %q", got, want)
+	}
+
+	got = footer(40, "", hints...)
+	if !strings.Contains(got, "↑/↓: navigate · ?: help · q: quit") {
+		t.Errorf("narrow footer = %q, want the middle hints dropped and help and quit kept", got)
+	}
+
+	km.Up.SetEnabled(false)
+	if h := pair(km.Up, km.Down, "navigate"); h.key != "↓" {
+		t.Errorf("pair with up off = %q, want just ↓", h.key)
+	}
+}
+
+func TestHelpSections(t *testing.T) {
+	descs := func(view View, section int) []string {
+		_, sections := helpSections(view)
+		var out []string
+		for _, e := range sections[section] {
+			if e.desc == "" {
+				out = append(out, e.b.Help().Desc)
+			} else {
+				out = append(out, e.desc)
+			}
+		}
+		return out
+	}
+	if d := descs(ViewList, 0); !slices.Contains(d, "next feed") {
+		t.Errorf("everywhere on the list = %q, want next feed", d)
+	}
+	if d := descs(ViewUser, 0); slices.Contains(d, "next feed") || slices.Contains(d, "previous feed") {
+		t.Errorf("everywhere on a profile = %q, want the feed tabs left out", d)
+	}
+	if d := descs(ViewUser, 1); !slices.Contains(d, "next activity tab") {
+		t.Errorf("profile help = %q, want next activity tab", d)
+	}
+}
+
+func TestAppKeySequences(t *testing.T) {
+	old := keymap
+	t.Cleanup(func() { SetKeyMap(old) })
+	km, err := NewKeyMap("vim", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetKeyMap(km)
+
+	a := NewApp(context.Background(), nil, "Top", nil)
+	a.view = ViewComments
+	a.comments = loadedThread(40)
+	press := func(keys ...string) {
+		for _, k := range keys {
+			a.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		}
+	}
+
+	press("G")
+	if a.comments.cursor != 6 {
+		t.Fatalf("cursor after G = %d, want the last comment", a.comments.cursor)
+	}
+	press("g")
+	if a.comments.cursor != 6 {
+		t.Errorf("cursor after g alone = %d, want it unmoved", a.comments.cursor)
+	}
+	press("g")
+	if a.comments.cursor != 0 {
+		t.Errorf("cursor after gg = %d, want 0", a.comments.cursor)
+	}
+	press("z", "a")
+	if !a.comments.flat[0].hidden {
+		t.Error("za did not collapse the comment")
+	}
+	press("n")
+	if a.comments.cursor != 4 {
+		t.Errorf("cursor after n = %d, want the next sibling, 4", a.comments.cursor)
+	}
+	// A key that doesn't finish a sequence counts by itself.
+	press("g", "k")
+	if a.comments.cursor != 0 {
+		t.Errorf("cursor after g k = %d, want 0", a.comments.cursor)
+	}
+}
@@ -2,8 +2,12 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/hexadecimoose/hncli/internal/api"
@@ -12,31 +16,71 @@ import (
 
 // StoriesLoaded is sent when story items have been fetched.
 type StoriesLoaded struct {
+	Items      []*api.Item
+	IDs        []int // the whole list, if Items is only its start
+	Err        error
+	StaleSince time.Time // when offline data was cached; zero if live
+}
+
+// LoadMore is sent when the list wants the items with the given IDs
+// appended to it.
+type LoadMore struct{ IDs []int }
+
+// MoreLoaded is sent when the items asked for by LoadMore have been fetched.
+type MoreLoaded struct {
 	Items []*api.Item
 	Err   error
 }
 
-// OpenItem is sent when the user wants to open a story's comments.
-type OpenItem struct{ ID int }
+// moreMargin is how close to the end of the loaded items the cursor gets
+// before the next page is fetched.
+const moreMargin = 5
+
+// OpenItem is sent when the user wants to open a story's comments. Focus,
+// if set, is the ID of a comment in the thread to select.
+type OpenItem struct {
+	ID    int
+	Focus int
+}
+
+// SearchMsg is sent when the user submits a query from the search prompt.
+type SearchMsg struct{ Query string }
 
 // OpenURL is sent when the user wants to open a URL.
 type OpenURL struct{ URL string }
 
 // ListModel is a bubbletea model for a scrollable list of stories.
 type ListModel struct {
-	title    string
-	items    []*api.Item
-	cursor   int
-	offset   int
-	height   int
-	width    int
-	loading  bool
-	err      error
+	title   string
+	items   []*api.Item
+	cursor  int
+	offset  int
+	height  int
+	width   int
+	loading bool
+	err     error
+	stale   time.Time
+
+	search    textinput.Model // the / prompt
+	searching bool            // the prompt has focus
+	back      bool            // there is a screen to go back to
+	tabbed    bool            // the tab bar is showing
+	crumbs    string          // breadcrumb shown before the title
+
+	ids  []int // the whole list, when items holds only its start
+	page int   // how many items to fetch at a time
+	more pager // fetching the rest of ids
+
+	before map[int]api.Item // items as first shown, if auto-refresh has changed them
 }
 
 // NewListModel creates a list model with a given title. Items are populated later.
 func NewListModel(title string) ListModel {
-	return ListModel{title: title, loading: true, height: 24, width: 80}
+	in := textinput.New()
+	in.Prompt = "  / "
+	in.Placeholder = "search Hacker News"
+	in.CharLimit = 200
+	return ListModel{title: title, loading: true, height: 24, width: 80, search: in}
 }
 
 func (m ListModel) Init() tea.Cmd { return nil }
@@ -47,58 +91,182 @@ func (m ListModel) Update(msg tea.Msg) (ListModel, tea.Cmd) {
 		m.loading = false
 		m.err = msg.Err
 		m.items = msg.Items
+		m.stale = msg.StaleSince
 		m.cursor = 0
 		m.offset = 0
+		m.ids = msg.IDs
+		m.more = pager{next: len(msg.Items)}
+		if len(msg.Items) > 0 {
+			if i := slices.Index(msg.IDs, msg.Items[len(msg.Items)-1].ID); i >= 0 {
+				m.more.next = i + 1
+			}
+		}
+		m.page = max(len(msg.Items), 10)
+		m.before = nil
+
+	case MoreLoaded:
+		m.items = append(m.items, msg.Items...)
+		if !m.more.done(len(msg.Items), len(msg.Items), msg.Err) {
+			return m, nil
+		}
+		return m.loadMore()
+
+	case Updated:
+		m.update(msg.Items)
 
 	case tea.WindowSizeMsg:
 		m.height = msg.Height - 4 // leave room for header + help
 		m.width = msg.Width
+		m.search.Width = max(10, msg.Width-len(m.search.Prompt)-2)
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "up", "k":
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		switch {
+		case key.Matches(msg, keymap.Search):
+			m.searching = true
+			m.search.SetValue("")
+			return m, m.search.Focus()
+		case key.Matches(msg, keymap.Back):
+			return m, func() tea.Msg { return BackMsg{} }
+		case key.Matches(msg, keymap.Up):
 			if m.cursor > 0 {
 				m.cursor--
 				if m.cursor < m.offset {
 					m.offset = m.cursor
 				}
 			}
-		case "down", "j":
+			return m.loadMore()
+		case key.Matches(msg, keymap.Down):
 			if m.cursor < len(m.items)-1 {
 				m.cursor++
 				if m.cursor >= m.offset+m.visibleLines() {
 					m.offset++
 				}
 			}
-		case "g":
+			return m.loadMore()
+		case key.Matches(msg, keymap.Top):
 			m.cursor = 0
 			m.offset = 0
-		case "G":
+		case key.Matches(msg, keymap.Bottom):
 			m.cursor = len(m.items) - 1
 			m.offset = max(0, m.cursor-m.visibleLines()+1)
-		case "enter":
+			return m.loadMore()
+		case key.Matches(msg, keymap.Open):
 			if len(m.items) > 0 {
-				return m, func() tea.Msg { return OpenItem{m.items[m.cursor].ID} }
+				item := m.items[m.cursor]
+				if item.Type == "comment" && item.StoryID != 0 {
+					// Search hit: show the comment in its thread.
+					return m, func() tea.Msg { return OpenItem{ID: item.StoryID, Focus: item.ID} }
+				}
+				return m, func() tea.Msg { return OpenItem{ID: item.ID} }
 			}
-		case "o":
+		case key.Matches(msg, keymap.OpenURL):
 			if len(m.items) > 0 {
 				u := m.items[m.cursor].URL
+				if m.items[m.cursor].Type == "comment" {
+					u = m.items[m.cursor].StoryURL
+				}
 				if u == "" {
 					u = fmt.Sprintf("https://news.ycombinator.com/item?id=%d", m.items[m.cursor].ID)
 				}
 				util.OpenBrowser(u) //nolint:errcheck
 			}
-		case "c":
+		case key.Matches(msg, keymap.OpenHN):
 			if len(m.items) > 0 {
 				util.OpenBrowser(fmt.Sprintf("https://news.ycombinator.com/item?id=%d", m.items[m.cursor].ID)) //nolint:errcheck
 			}
+		case key.Matches(msg, keymap.Author):
+			if len(m.items) > 0 && m.items[m.cursor].By != "" {
+				by := m.items[m.cursor].By
+				return m, func() tea.Msg { return OpenUser{Username: by} }
+			}
+		}
+
+	default:
+		if m.searching {
+			// Cursor blinks.
+			var cmd tea.Cmd
+			m.search, cmd = m.search.Update(msg)
+			return m, cmd
 		}
 	}
 	return m, nil
 }
 
+// update replaces items that auto-refresh found changed with their fresh
+// copies, remembering what they were when first shown so that the change
+// can be marked.
+func (m *ListModel) update(fresh []*api.Item) {
+	for _, f := range fresh {
+		i := slices.IndexFunc(m.items, func(item *api.Item) bool { return item.ID == f.ID })
+		if i < 0 {
+			continue
+		}
+		if m.before == nil {
+			m.before = make(map[int]api.Item)
+		}
+		old := m.items[i]
+		if _, ok := m.before[f.ID]; !ok {
+			m.before[f.ID] = *old
+		}
+		// Keep what a search hit knew about its story.
+		f.StoryID, f.StoryTitle, f.StoryURL = old.StoryID, old.StoryTitle, old.StoryURL
+		m.items[i] = f
+	}
+}
+
+// delta marks a change made since a screen was opened, e.g. " +5", or
+// returns "" if there was none.
+func delta(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return ChangeStyle.Render(fmt.Sprintf(" %+d", n))
+}
+
+// loadMore asks for the next page of the list once the cursor is near the
+// end of the items fetched so far.
+func (m ListModel) loadMore() (ListModel, tea.Cmd) {
+	if m.more.fetching > 0 || m.more.next >= len(m.ids) || !m.nearEnd() {
+		return m, nil
+	}
+	ids := m.ids[m.more.next:min(m.more.next+m.page, len(m.ids))]
+	m.more.start(len(ids))
+	return m, func() tea.Msg { return LoadMore{IDs: ids} }
+}
+
+// nearEnd reports whether the cursor is close enough to the end of the
+// items fetched so far for the next page to be wanted.
+func (m ListModel) nearEnd() bool { return m.cursor >= len(m.items)-moreMargin }
+
+// updateSearch handles a key press while the search prompt has focus.
+func (m ListModel) updateSearch(msg tea.KeyMsg) (ListModel, tea.Cmd) {
+	switch {
+	case key.Matches(msg, keymap.Cancel):
+		m.searching = false
+		m.search.Blur()
+		return m, nil
+	case key.Matches(msg, keymap.Submit):
+		q := strings.TrimSpace(m.search.Value())
+		m.searching = false
+		m.search.Blur()
+		if q == "" {
+			return m, nil
+		}
+		return m, func() tea.Msg { return SearchMsg{Query: q} }
+	}
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	return m, cmd
+}
+
 func (m ListModel) visibleLines() int {
-	// Each story takes 2 lines.
+	// Each story takes 2 lines, plus one for the loading more… line.
+	if m.ids != nil {
+		return (m.height - 1) / 2
+	}
 	return m.height / 2
 }
 
@@ -106,7 +274,11 @@ func (m ListModel) View() string {
 	var b strings.Builder
 
 	// Header.
-	b.WriteString(HeaderStyle.Width(m.width).Render("  " + m.title))
+	header := "  " + withCrumbs(m.crumbs, m.title)
+	if !m.stale.IsZero() {
+		header += "  ·  offline, stale since " + staleLabel(m.stale)
+	}
+	b.WriteString(HeaderStyle.Width(m.width).Render(header))
 	b.WriteString("\n\n")
 
 	if m.loading {
@@ -115,10 +287,12 @@ func (m ListModel) View() string {
 	}
 	if m.err != nil {
 		b.WriteString(fmt.Sprintf("  Error: %v", m.err))
+		b.WriteString("\n\n" + m.footer())
 		return b.String()
 	}
 	if len(m.items) == 0 {
 		b.WriteString(StatusStyle.Render("  No stories found."))
+		b.WriteString("\n\n" + m.footer())
 		return b.String()
 	}
 
@@ -134,44 +308,96 @@ func (m ListModel) View() string {
 
 		// Line 1: index + title + score.
 		idx := IndexStyle.Render(fmt.Sprintf("%d.", i+1))
+		title := item.Title
+		if item.Type == "comment" {
+			title = item.StoryTitle
+		}
 		var titleStr string
 		if selected {
-			titleStr = SelectedTitleStyle.Render(item.Title)
+			titleStr = SelectedTitleStyle.Render(title)
 		} else {
-			titleStr = TitleStyle.Render(item.Title)
+			titleStr = TitleStyle.Render(title)
+		}
+		line1 := idx + " " + titleStr
+		if item.Type == "poll" {
+			line1 = idx + " " + BadgeStyle.Render("poll") + " " + titleStr
+		}
+		before, changed := m.before[item.ID]
+		if !changed {
+			before = *item
+		}
+		if item.Type != "comment" {
+			line1 += "  " + ScoreStyle.Render(fmt.Sprintf("▲ %d", item.Score)) + delta(item.Score-before.Score)
 		}
-		score := ScoreStyle.Render(fmt.Sprintf("▲ %d", item.Score))
-		line1 := idx + " " + titleStr + "  " + score
 
 		// Line 2: meta.
-		host := ""
-		if item.URL != "" {
-			host = URLStyle.Render(hostname(item.URL))
-		}
-		metaText := fmt.Sprintf("%s comments · by %s · %s", commentsStr(item.Descendants), item.By, item.Age())
 		var meta string
-		if host != "" {
-			meta = "    " + host + MetaStyle.Render(" · "+metaText)
+		if item.Type == "comment" {
+			// Comment search hit: who said what, under the story title.
+			metaText := fmt.Sprintf("comment by %s · %s · ", item.By, item.Age())
+			excerpt := strings.Join(strings.Fields(util.StripHTML(item.Text)), " ")
+			meta = "    " + MetaStyle.Render(metaText+util.Truncate(excerpt, max(10, m.width-len(metaText)-8)))
 		} else {
-			meta = "    " + MetaStyle.Render(metaText)
+			host := ""
+			if item.URL != "" {
+				host = URLStyle.Render(util.Hostname(item.URL))
+			}
+			metaText := MetaStyle.Render(commentsStr(item.Descendants)) + delta(item.Descendants-before.Descendants) +
+				MetaStyle.Render(fmt.Sprintf(" comments · by %s · %s", item.By, item.Age()))
+			if host != "" {
+				meta = "    " + host + MetaStyle.Render(" · ") + metaText
+			} else {
+				meta = "    " + metaText
+			}
 		}
 
 		prefix := "  "
 		if selected {
-			prefix = lipgloss.NewStyle().Foreground(orange).Render("▶ ")
+			prefix = lipgloss.NewStyle().Foreground(theme.Accent).Render("▶ ")
 		}
 
 		b.WriteString(prefix + line1 + "\n")
 		b.WriteString("  " + meta + "\n")
 	}
 
-	// Help bar.
+	if status := m.more.status(len(m.ids), "stories", m.nearEnd()); status != "" {
+		b.WriteString(StatusStyle.Render(status) + "\n")
+	} else if m.ids != nil {
+		b.WriteString("\n")
+	}
+
+	// Help bar, or the search prompt while it is open.
 	b.WriteString("\n")
-	b.WriteString(HelpStyle.Render("  ↑/↓ navigate · enter: comments · o: open url · c: open hn · r: refresh · q: quit"))
+	b.WriteString(m.footer())
 
 	return b.String()
 }
 
+// footer renders the search prompt while it is open, and the key help otherwise.
+func (m ListModel) footer() string {
+	if m.searching {
+		return m.search.View()
+	}
+	k := keymap
+	back, feeds := bind(k.Back, "back"), hint{}
+	if !m.back {
+		back = hint{}
+	}
+	if m.tabbed {
+		feeds = pair(k.NextTab, k.GoToTab, "feeds")
+	}
+	return footer(m.width, "",
+		pair(k.Up, k.Down, "navigate"), bind(k.Open, "comments"), bind(k.Author, "author"),
+		bind(k.OpenURL, "open url"), bind(k.OpenHN, "open hn"), bind(k.Search, "search"),
+		bind(k.Refresh, "refresh"), back, feeds, bind(k.Help, "help"), bind(k.Quit, "quit"))
+}
+
+// staleLabel describes when offline data was fetched, e.g.
+// "Jan 2 15:04 (3 hours ago)".
+func staleLabel(t time.Time) string {
+	return fmt.Sprintf("%s (%s)", t.Local().Format("Jan 2 15:04"), api.Age(t.Unix()))
+}
+
 func commentsStr(n int) string {
 	if n == 1 {
 		return "1"
@@ -179,19 +405,6 @@ func commentsStr(n int) string {
 	return fmt.Sprintf("%d", n)
 }
 
-func hostname(rawURL string) string {
-	// Trim scheme and www.
-	s := rawURL
-	for _, prefix := range []string{"https://", "http://"} {
-		s = strings.TrimPrefix(s, prefix)
-	}
-	s = strings.TrimPrefix(s, "www.")
-	if i := strings.IndexByte(s, '/'); i >= 0 {
-		s = s[:i]
-	}
-	return s
-}
-
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hexadecimoose/hncli/internal/api"
+)
+
+// Option configures the TUI.
+type Option func(*App)
+
+// WithAutoRefresh makes the TUI check every d for changes to the story list
+// or thread on screen and apply them in place. Zero turns it off.
+func WithAutoRefresh(d time.Duration) Option {
+	return func(a *App) { a.interval = d }
+}
+
+// WithStreaming makes auto-refresh follow the updates endpoint's event
+// stream, applying changes as soon as they are pushed rather than checking
+// every interval. A dropped stream is reconnected by the client; the
+// interval is only fallen back on if the stream ends for good.
+func WithStreaming(on bool) Option {
+	return func(a *App) { a.stream = on }
+}
+
+// maxNewItems bounds how many of the items posted since the last check are
+// looked through for replies to the thread on screen.
+const maxNewItems = 200
+
+// pollTick is sent when it is time to check for changes.
+type pollTick struct{}
+
+// streamed is sent when the updates stream reports recently changed items.
+// ok is false once the stream has ended.
+type streamed struct {
+	items []int
+	ok    bool
+}
+
+// Updated is sent when a check for changes is done. Items holds fresh
+// copies of the items on screen that changed; Replies holds the comment
+// trees that have arrived in a thread, by the ID of the item they reply to.
+// Err is the first thing that failed; whatever was fetched is still there.
+type Updated struct {
+	Items   []*api.Item
+	Replies map[int][]*api.Comment
+	MaxItem int // largest item ID looked through, 0 if not asked for
+	Err     error
+}
+
+// tick schedules the next check for changes, if auto-refresh is on and
+// changes are not being streamed.
+func (a *App) tick() tea.Cmd {
+	if a.interval <= 0 || a.updates != nil {
+		return nil
+	}
+	return tea.Tick(a.interval, func(time.Time) tea.Msg { return pollTick{} })
+}
+
+// next waits for the updates stream to report changes.
+func (a *App) next() tea.Cmd {
+	ch := a.updates
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		u, ok := <-ch
+		if !ok {
+			return streamed{}
+		}
+		return streamed{items: u.Items, ok: true}
+	}
+}
+
+// poll starts a check for changes to the active screen, unless one is
+// already running. When streaming, only the items reported since the last
+// check are looked at.
+func (a *App) poll() tea.Cmd {
+	if a.polling {
+		return nil
+	}
+	var changed []int
+	if a.updates != nil {
+		// A screen still loading will be fresh anyway, so what was
+		// streamed meanwhile is dropped rather than kept piling up.
+		changed, a.pending = a.pending, nil
+		if len(changed) == 0 {
+			return nil
+		}
+	}
+	ids, thread := a.watched()
+	if len(ids) == 0 || a.loading() {
+		return nil
+	}
+	a.polling = true
+	a.checking = changed
+	return PollCmd(a.ctx, a.apiClient, ids, thread, a.maxItem, changed)
+}
+
+// watched returns the IDs of the items on the active screen. For a thread,
+// each maps to its depth, the story's being -1.
+func (a *App) watched() (ids map[int]int, thread bool) {
+	ids = make(map[int]int)
+	switch a.view {
+	case ViewList:
+		for _, item := range a.list.items {
+			ids[item.ID] = 0
+		}
+	case ViewComments:
+		if a.comments.story == nil {
+			return nil, false
+		}
+		ids[a.comments.story.ID] = -1
+		var walk func([]*api.Comment)
+		walk = func(cs []*api.Comment) {
+			for _, c := range cs {
+				ids[c.ID] = c.Depth
+				walk(c.Replies)
+			}
+		}
+		walk(a.comments.tree)
+		thread = true
+	}
+	return ids, thread
+}
+
+// PollCmd checks which of the watched items changed recently and fetches
+// them afresh. recent lists the IDs of the items that changed; if nil, they
+// are fetched from the updates endpoint. For a thread it also collects
+// replies that are not in watched yet: new kids of the items that changed,
+// and comments on the thread among the items posted after since, the
+// largest item ID looked through at the previous check (0 for none).
+func PollCmd(ctx context.Context, client *api.Client, watched map[int]int, thread bool, since int, recent []int) tea.Cmd {
+	return func() tea.Msg {
+		if recent == nil {
+			upd, err := client.UpdatesContext(ctx)
+			if err != nil {
+				return Updated{Err: err}
+			}
+			recent = upd.Items
+		}
+		var changed []int
+		for _, id := range recent {
+			if _, ok := watched[id]; ok {
+				changed = append(changed, id)
+			}
+		}
+		var msg Updated
+		if len(changed) > 0 {
+			msg.Items, msg.Err = client.RefreshItemsContext(ctx, changed)
+		}
+		if !thread || ctx.Err() != nil {
+			return msg
+		}
+
+		kids := make(map[int][]int) // parent ID → new replies
+		add := func(parent, id int) {
+			if _, ok := watched[id]; ok || slices.Contains(kids[parent], id) {
+				return
+			}
+			kids[parent] = append(kids[parent], id)
+		}
+		for _, item := range msg.Items {
+			for _, k := range item.Kids {
+				add(item.ID, k)
+			}
+		}
+		if top, err := client.MaxItemContext(ctx); err == nil {
+			msg.MaxItem = top
+			if since > 0 && top > since {
+				var ids []int
+				for id := max(since+1, top-maxNewItems+1); id <= top; id++ {
+					ids = append(ids, id)
+				}
+				// Fresh, so that an ID that held nothing a moment ago is
+				// not answered from the cache.
+				posted, _ := client.RefreshItemsContext(ctx, ids) // what failed is caught next time round, if it changes again
+				found := make(map[int]bool, len(posted))
+				for _, item := range posted {
+					found[item.ID] = true
+					if _, ok := watched[item.Parent]; ok && item.Type == "comment" {
+						add(item.Parent, item.ID)
+					}
+				}
+				// The newest IDs may hold nothing for a moment before their
+				// items appear, so the next check starts again from the
+				// first of them that came back empty.
+				for _, id := range ids {
+					if top-id < api.SettleWindow && !found[id] {
+						msg.MaxItem = id - 1
+						break
+					}
+				}
+			}
+		}
+		if len(kids) > 0 {
+			msg.Replies = make(map[int][]*api.Comment, len(kids))
+			for parent, ids := range kids {
+				msg.Replies[parent] = client.RepliesContext(ctx, ids, watched[parent]+1)
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return Updated{Err: err}
+		}
+		return msg
+	}
+}
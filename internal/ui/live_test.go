@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hexadecimoose/hncli/internal/api"
+	"github.com/hexadecimoose/hncli/internal/api/apitest"
+)
+
+func TestPollCmdWaitsForNewestIDs(t *testing.T) {
+	srv := apitest.NewServer()
+	defer srv.Close()
+	srv.AddItems(&api.Item{ID: 1, Type: "story"}, &api.Item{ID: 2, Type: "comment", Parent: 1},
+		&api.Item{ID: 5, Type: "story"})
+	c := srv.Client(api.WithCache(api.NewCache(t.TempDir(), 0)))
+	watched := map[int]int{1: -1, 2: 0}
+	poll := func(since int) Updated {
+		t.Helper()
+		msg := PollCmd(context.Background(), c, watched, true, since, []int{})().(Updated)
+		if msg.Err != nil {
+			t.Fatal(msg.Err)
+		}
+		return msg
+	}
+
+	// Items 3 and 4 hold nothing yet.
+	msg := poll(2)
+	if msg.MaxItem != 2 || len(msg.Replies) != 0 {
+		t.Fatalf("poll with 3 empty = max item %d, replies %v; want 2 and none", msg.MaxItem, msg.Replies)
+	}
+
+	srv.AddItems(&api.Item{ID: 3, Type: "comment", Parent: 1}, &api.Item{ID: 4, Type: "comment", Parent: 2})
+	msg = poll(msg.MaxItem)
+	if msg.MaxItem != 5 {
+		t.Errorf("max item once 3 and 4 appeared = %d, want 5", msg.MaxItem)
+	}
+	if r := msg.Replies[1]; len(r) != 1 || r[0].ID != 3 {
+		t.Errorf("replies to the story = %v, want comment 3", r)
+	}
+	if r := msg.Replies[2]; len(r) != 1 || r[0].ID != 4 || r[0].Depth != 1 {
+		t.Errorf("replies to comment 2 = %v, want comment 4 at depth 1", r)
+	}
+}
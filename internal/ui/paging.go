@@ -0,0 +1,54 @@
+package ui
+
+import "fmt"
+
+// pager keeps track of fetching a long list of IDs a page at a time, for
+// the story list and a profile's submissions.
+type pager struct {
+	next     int   // index of the first ID not yet fetched
+	fetching int   // number of IDs being fetched, 0 if none
+	err      error // why the last fetch failed
+	stalled  bool  // the last page added nothing to what is shown
+}
+
+// start records that the next n IDs are being fetched.
+func (p *pager) start(n int) {
+	p.fetching = n
+	p.stalled = false
+}
+
+// done records that the page being fetched came back with n items, added
+// of them to what is shown, or failed with err. It reports whether to go
+// on to the next page: not after a failure, in case it keeps failing, nor
+// after a page that added nothing, so that a view that may stay empty
+// doesn't walk the whole list. Either way the user moving asks again.
+func (p *pager) done(n, added int, err error) bool {
+	if n == 0 && err != nil {
+		p.fetching, p.err = 0, err
+		return false
+	}
+	// Items that failed to load are skipped.
+	p.next += p.fetching
+	p.fetching, p.err = 0, nil
+	p.stalled = added == 0
+	return !p.stalled
+}
+
+// status describes how fetching a list of total things, such as "stories",
+// is going: what is being fetched, why it failed, or, at the end of what
+// is shown after a stall, how far it got. It is "" otherwise.
+func (p pager) status(total int, things string, atEnd bool) string {
+	switch {
+	case p.fetching > 0:
+		return fmt.Sprintf("  Loading more… (%d of %d %s)", p.next, total, things)
+	case p.err != nil:
+		return fmt.Sprintf("  Couldn't load more: %v", p.err)
+	case p.stalled && atEnd && p.next < total:
+		s := fmt.Sprintf("  %d of %d %s looked through", p.next, total, things)
+		if h := bind(keymap.Down, "look further"); h.key != "" {
+			s += " · " + h.key + ": " + h.desc
+		}
+		return s
+	}
+	return ""
+}
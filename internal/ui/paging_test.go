@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPagerDone(t *testing.T) {
+	failed := errors.New("offline")
+	tests := []struct {
+		name     string
+		n, added int
+		err      error
+		fetchOn  bool
+		next     int
+		stalled  bool
+	}{
+		{"page added to what is shown", 10, 4, nil, true, 30, false},
+		{"page added nothing", 10, 0, nil, false, 30, true},
+		{"every item failed", 0, 0, nil, false, 30, true},
+		{"some items failed", 6, 6, failed, true, 30, false},
+		{"fetch failed", 0, 0, failed, false, 20, false},
+	}
+	for _, tt := range tests {
+		p := pager{next: 20}
+		p.start(10)
+		if got := p.done(tt.n, tt.added, tt.err); got != tt.fetchOn {
+			t.Errorf("%s: done = %v, want %v", tt.name, got, tt.fetchOn)
+		}
+		if p.fetching != 0 || p.next != tt.next || p.stalled != tt.stalled {
+			t.Errorf("%s: pager = %+v, want next %d, stalled %v", tt.name, p, tt.next, tt.stalled)
+		}
+		if (p.err != nil) != (tt.n == 0 && tt.err != nil) {
+			t.Errorf("%s: err = %v", tt.name, p.err)
+		}
+	}
+}
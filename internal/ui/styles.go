@@ -2,86 +2,204 @@ package ui
 
 import "github.com/charmbracelet/lipgloss"
 
+// Theme is a colour scheme for the TUI.
+type Theme struct {
+	Accent   lipgloss.Color   // titles, authors and the header, HN orange by default
+	OnAccent lipgloss.Color   // text on an Accent or Warn background
+	Text     lipgloss.Color   // titles and comment text
+	Subtle   lipgloss.Color   // metadata such as ages and comment counts
+	Dim      lipgloss.Color   // indices, separators and help
+	Good     lipgloss.Color   // URLs and changes found by auto-refresh
+	Warn     lipgloss.Color   // badges, karma and collapsed threads
+	Depth    []lipgloss.Color // comment indent bars, by depth
+}
+
+// Themes are the built-in colour schemes, by name.
+var Themes = map[string]Theme{
+	// hn is for dark terminals, in Hacker News orange.
+	"hn": {
+		Accent:   "#FF6600",
+		OnAccent: "#000000",
+		Text:     "#FFFAF0",
+		Subtle:   "#6C7D8C",
+		Dim:      "#3D4B56",
+		Good:     "#72C472",
+		Warn:     "#E8C547",
+		Depth:    []lipgloss.Color{"#FF6600", "#E8C547", "#72C472", "#5BC8DB", "#A78BFA"},
+	},
+	// light is for light terminals.
+	"light": {
+		Accent:   "#C24E00",
+		OnAccent: "#FFFFFF",
+		Text:     "#1C1C1C",
+		Subtle:   "#56636F",
+		Dim:      "#8C98A3",
+		Good:     "#2E7D32",
+		Warn:     "#A56A00",
+		Depth:    []lipgloss.Color{"#C24E00", "#A56A00", "#2E7D32", "#00838F", "#6A4FC4"},
+	},
+	// mono uses shades of grey only, leaning on bold and underline.
+	"mono": {
+		Accent:   "15",
+		OnAccent: "0",
+		Text:     "252",
+		Subtle:   "245",
+		Dim:      "240",
+		Good:     "250",
+		Warn:     "250",
+		Depth:    []lipgloss.Color{"250", "245", "240"},
+	},
+}
+
+// DefaultTheme is the name of the theme used unless SetTheme is called.
+const DefaultTheme = "hn"
+
 var (
-	// Colours.
-	orange    = lipgloss.Color("#FF6600") // HN orange
-	subtleGray = lipgloss.Color("#6C7D8C")
-	dimGray   = lipgloss.Color("#3D4B56")
-	white     = lipgloss.Color("#FFFAF0")
-	green     = lipgloss.Color("#72C472")
-	yellow    = lipgloss.Color("#E8C547")
+	theme Theme
 
 	// Story list styles.
+	TitleStyle         lipgloss.Style
+	SelectedTitleStyle lipgloss.Style
+	MetaStyle          lipgloss.Style
+	ScoreStyle         lipgloss.Style
+
+	// BadgeStyle marks items that aren't plain stories, e.g. polls.
+	BadgeStyle lipgloss.Style
+
+	// ChangeStyle marks what auto-refresh found changed, e.g. a higher score.
+	ChangeStyle lipgloss.Style
+
+	IndexStyle lipgloss.Style
+
+	// Comment styles.
+	CommentAuthorStyle lipgloss.Style
+	CommentTimeStyle   lipgloss.Style
+	CommentTextStyle   lipgloss.Style
+	IndentStyle        lipgloss.Style
+	SelectedBarStyle   lipgloss.Style
+	CollapsedStyle     lipgloss.Style
+
+	// Header / title bar.
+	HeaderStyle lipgloss.Style
+
+	// Tab bar.
+	TabStyle       lipgloss.Style
+	ActiveTabStyle lipgloss.Style
+
+	// Status bar.
+	StatusStyle lipgloss.Style
+
+	// User profile.
+	UserNameStyle  lipgloss.Style
+	UserKarmaStyle lipgloss.Style
+
+	// Help bar.
+	HelpStyle lipgloss.Style
+
+	// Separator.
+	SepStyle lipgloss.Style
+
+	// URL.
+	URLStyle lipgloss.Style
+)
+
+func init() { SetTheme(Themes[DefaultTheme]) }
+
+// SetTheme restyles the TUI with t. It must be called before the TUI is
+// started.
+func SetTheme(t Theme) {
+	theme = t
+
 	TitleStyle = lipgloss.NewStyle().
-			Foreground(white).
-			Bold(true)
+		Foreground(t.Text).
+		Bold(true)
 
 	SelectedTitleStyle = lipgloss.NewStyle().
-				Foreground(orange).
-				Bold(true)
+		Foreground(t.Accent).
+		Bold(true)
 
 	MetaStyle = lipgloss.NewStyle().
-			Foreground(subtleGray)
+		Foreground(t.Subtle)
 
 	ScoreStyle = lipgloss.NewStyle().
-			Foreground(orange).
-			Bold(true)
+		Foreground(t.Accent).
+		Bold(true)
+
+	BadgeStyle = lipgloss.NewStyle().
+		Foreground(t.OnAccent).
+		Background(t.Warn).
+		Padding(0, 1)
+
+	ChangeStyle = lipgloss.NewStyle().
+		Foreground(t.Good).
+		Bold(true)
 
 	IndexStyle = lipgloss.NewStyle().
-			Foreground(dimGray).
-			Width(4).
-			Align(lipgloss.Right)
+		Foreground(t.Dim).
+		Width(4).
+		Align(lipgloss.Right)
 
-	// Comment styles.
 	CommentAuthorStyle = lipgloss.NewStyle().
-				Foreground(orange).
-				Bold(true)
+		Foreground(t.Accent).
+		Bold(true)
 
 	CommentTimeStyle = lipgloss.NewStyle().
-				Foreground(subtleGray)
+		Foreground(t.Subtle)
 
 	CommentTextStyle = lipgloss.NewStyle().
-				Foreground(white)
+		Foreground(t.Text)
 
 	IndentStyle = lipgloss.NewStyle().
-			Foreground(dimGray)
+		Foreground(t.Dim)
+
+	SelectedBarStyle = lipgloss.NewStyle().
+		Foreground(t.Text).
+		Bold(true)
+
+	CollapsedStyle = lipgloss.NewStyle().
+		Foreground(t.Warn).
+		Italic(true)
 
-	// Header / title bar.
 	HeaderStyle = lipgloss.NewStyle().
-			Background(orange).
-			Foreground(lipgloss.Color("#000000")).
-			Bold(true).
-			Padding(0, 1)
+		Background(t.Accent).
+		Foreground(t.OnAccent).
+		Bold(true).
+		Padding(0, 1)
+
+	TabStyle = lipgloss.NewStyle().
+		Foreground(t.Subtle).
+		Padding(0, 1)
+
+	ActiveTabStyle = lipgloss.NewStyle().
+		Foreground(t.Accent).
+		Bold(true).
+		Underline(true).
+		Padding(0, 1)
 
-	// Status bar.
 	StatusStyle = lipgloss.NewStyle().
-			Foreground(subtleGray).
-			Italic(true)
+		Foreground(t.Subtle).
+		Italic(true)
 
-	// User profile.
 	UserNameStyle = lipgloss.NewStyle().
-			Foreground(orange).
-			Bold(true).
-			Underline(true)
+		Foreground(t.Accent).
+		Bold(true).
+		Underline(true)
 
 	UserKarmaStyle = lipgloss.NewStyle().
-			Foreground(yellow).
-			Bold(true)
+		Foreground(t.Warn).
+		Bold(true)
 
-	// Help bar.
 	HelpStyle = lipgloss.NewStyle().
-			Foreground(dimGray)
+		Foreground(t.Dim)
 
-	// Separator.
 	SepStyle = lipgloss.NewStyle().
-			Foreground(dimGray).
-			SetString("  ·  ")
+		Foreground(t.Dim).
+		SetString("  ·  ")
 
-	// URL.
 	URLStyle = lipgloss.NewStyle().
-			Foreground(green).
-			Italic(true)
-)
+		Foreground(t.Good).
+		Italic(true)
+}
 
 // Sep renders the separator bullet.
 func Sep() string { return SepStyle.Render() }
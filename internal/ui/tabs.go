@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hexadecimoose/hncli/internal/api"
+)
+
+// Tab is a story list offered in the TUI's tab bar.
+type Tab struct {
+	Name   string // label in the tab bar, e.g. "Top"
+	Title  string // header of the list
+	Loader Loader
+}
+
+// tabState is a tab together with everything shown in it: its active
+// screen and its own back/forward history.
+type tabState struct {
+	Tab
+	screen  screen
+	back    []screen
+	forward []screen
+	started bool // visited at least once, so screen is set
+}
+
+// switchTab stashes the active tab and shows tab i instead, loading it if
+// this is its first visit or if it was left mid-load.
+func (a *App) switchTab(i int) tea.Cmd {
+	if i < 0 || i >= len(a.tabs) || i == a.active {
+		return nil
+	}
+	a.cancelLoad()
+	t := &a.tabs[a.active]
+	t.screen, t.back, t.forward = a.stash(), a.back, a.forward
+
+	a.active = i
+	t = &a.tabs[i]
+	if !t.started {
+		t.started = true
+		t.screen = screen{
+			view:     ViewList,
+			list:     NewListModel(t.Title),
+			loader:   t.Loader,
+			comments: NewCommentsModel(),
+			user:     NewUserModel(),
+		}
+		if a.size.Width > 0 {
+			t.screen.resize(a.innerSize())
+		}
+	}
+	a.set(t.screen)
+	a.back, a.forward = t.back, t.forward
+	return a.resume()
+}
+
+// innerSize is the terminal size left for the active screen once the tab
+// bar, if any, has taken its line.
+func (a *App) innerSize() tea.WindowSizeMsg {
+	msg := a.size
+	if len(a.tabs) > 0 {
+		msg.Height--
+	}
+	return msg
+}
+
+// tabBar renders the tab bar. The first nine tabs are numbered for their
+// shortcut keys.
+func (a *App) tabBar() string {
+	var parts []string
+	for i, t := range a.tabs {
+		label := t.Name
+		if i < 9 {
+			label = fmt.Sprintf("%d %s", i+1, t.Name)
+		}
+		if i == a.active {
+			parts = append(parts, ActiveTabStyle.Render(label))
+		} else {
+			parts = append(parts, TabStyle.Render(label))
+		}
+	}
+	return " " + strings.Join(parts, "")
+}
+
+// RunTabs starts the TUI with a tab per story list, showing tabs[active]
+// first. Other tabs are loaded when first visited.
+func RunTabs(ctx context.Context, client *api.Client, tabs []Tab, active int, opts ...Option) error {
+	app := NewApp(ctx, client, tabs[active].Title, tabs[active].Loader)
+	app.tabs = make([]tabState, len(tabs))
+	for i, t := range tabs {
+		app.tabs[i] = tabState{Tab: t}
+	}
+	app.active = active
+	app.tabs[active].started = true
+	return run(app, app.reload(), opts)
+}
@@ -5,27 +5,83 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/hexadecimoose/hncli/internal/api"
 	"github.com/hexadecimoose/hncli/internal/util"
 )
 
-// UserLoaded is sent when a user profile has been fetched.
+// UserLoaded is sent when a user profile has been fetched, together with
+// the first UserPage of their submissions.
 type UserLoaded struct {
 	User  *api.User
 	Items []*api.Item
 	Err   error
 }
 
+// LoadActivity asks for N more of a user's submissions, starting Offset
+// items into their history.
+type LoadActivity struct {
+	Offset int
+	N      int
+}
+
+// ActivityLoaded is sent when the submissions asked for by LoadActivity
+// have been fetched.
+type ActivityLoaded struct {
+	Items []*api.Item
+	Err   error
+}
+
+// OpenUser is sent when the user wants to open someone's profile.
+type OpenUser struct{ Username string }
+
+// UserPage is how many submissions are fetched at a time. Every kind of
+// submission counts, so a page may add little to the tab being looked at.
+const UserPage = 30
+
+// The tabs of a profile, each listing one kind of submission.
+const (
+	tabStories = iota
+	tabComments
+	tabPolls
+)
+
+var activityNames = [...]string{"Stories", "Comments", "Polls"}
+
+// activity is the list of submissions in one tab of a profile.
+type activity struct {
+	items  []*api.Item
+	cursor int // index into items of the selected submission
+	scroll int // first submission shown
+}
+
+// activityTab returns the tab item is listed in. Jobs go with stories.
+func activityTab(item *api.Item) int {
+	switch item.Type {
+	case "comment":
+		return tabComments
+	case "poll":
+		return tabPolls
+	default:
+		return tabStories
+	}
+}
+
 // UserModel is a bubbletea model for a user profile view.
 type UserModel struct {
 	user    *api.User
-	items   []*api.Item
-	scroll  int
+	tabs    [len(activityNames)]activity
+	tab     int   // index into tabs of the one shown
+	more    pager // fetching the rest of user.Submitted
 	height  int
 	width   int
 	loading bool
 	err     error
+
+	username string // user shown, for reloading
+	crumbs   string // breadcrumb shown before the title
 }
 
 // NewUserModel returns a loading user model.
@@ -41,32 +97,124 @@ func (m UserModel) Update(msg tea.Msg) (UserModel, tea.Cmd) {
 		m.loading = false
 		m.err = msg.Err
 		m.user = msg.User
-		m.items = msg.Items
-		m.scroll = 0
+		m.tabs = [len(activityNames)]activity{}
+		m.add(msg.Items)
+		m.more = pager{}
+		if m.user != nil {
+			m.more.start(min(UserPage, len(m.user.Submitted)))
+			if !m.more.done(len(msg.Items), len(m.tabs[m.tab].items), nil) {
+				return m, nil
+			}
+		}
+		return m.loadMore()
+
+	case ActivityLoaded:
+		found := len(m.tabs[m.tab].items)
+		m.add(msg.Items)
+		if !m.more.done(len(msg.Items), len(m.tabs[m.tab].items)-found, msg.Err) {
+			return m, nil
+		}
+		return m.loadMore()
 
 	case tea.WindowSizeMsg:
 		m.height = msg.Height - 4
 		m.width = msg.Width
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "up", "k":
-			if m.scroll > 0 {
-				m.scroll--
+		t := &m.tabs[m.tab]
+		switch {
+		case key.Matches(msg, keymap.Up):
+			if t.cursor > 0 {
+				t.cursor--
+				t.scroll = min(t.scroll, t.cursor)
+			}
+			return m.loadMore()
+		case key.Matches(msg, keymap.Down):
+			if t.cursor < len(t.items)-1 {
+				t.cursor++
+				t.scroll = max(t.scroll, t.cursor-m.visibleItems()+1)
+			}
+			return m.loadMore()
+		case key.Matches(msg, keymap.NextTab):
+			m.tab = (m.tab + 1) % len(m.tabs)
+			return m.loadMore()
+		case key.Matches(msg, keymap.PrevTab):
+			m.tab = (m.tab + len(m.tabs) - 1) % len(m.tabs)
+			return m.loadMore()
+		case key.Matches(msg, keymap.Open):
+			if item := m.selected(); item != nil {
+				msg := OpenItem{ID: item.ID}
+				if item.Type == "comment" && item.StoryID != 0 {
+					msg = OpenItem{ID: item.StoryID, Focus: item.ID}
+				}
+				return m, func() tea.Msg { return msg }
+			}
+		case key.Matches(msg, keymap.OpenHN):
+			if item := m.selected(); item != nil {
+				util.OpenBrowser(fmt.Sprintf("https://news.ycombinator.com/item?id=%d", item.ID)) //nolint:errcheck
 			}
-		case "down", "j":
-			m.scroll++
-		case "o":
+		case key.Matches(msg, keymap.OpenURL):
 			if m.user != nil {
 				util.OpenBrowser(fmt.Sprintf("https://news.ycombinator.com/user?id=%s", m.user.ID)) //nolint:errcheck
 			}
-		case "q", "backspace", "esc", "left", "h":
+		case key.Matches(msg, keymap.Back):
 			return m, func() tea.Msg { return BackMsg{} }
 		}
 	}
 	return m, nil
 }
 
+// add sorts submissions into their tabs, keeping them newest first.
+func (m *UserModel) add(items []*api.Item) {
+	for _, item := range items {
+		t := &m.tabs[activityTab(item)]
+		t.items = append(t.items, item)
+	}
+}
+
+// selected returns the selected submission in the tab shown, if any.
+func (m UserModel) selected() *api.Item {
+	t := m.tabs[m.tab]
+	if len(t.items) == 0 {
+		return nil
+	}
+	return t.items[t.cursor]
+}
+
+// loadMore asks for the next page of submissions while the tab shown has
+// fewer than a screenful left below the cursor. Pages are fetched one after
+// another until one adds nothing to the tab; after that, only when the user
+// moves or switches tabs.
+func (m UserModel) loadMore() (UserModel, tea.Cmd) {
+	if m.user == nil || m.more.fetching > 0 || m.more.next >= len(m.user.Submitted) || !m.nearEnd() {
+		return m, nil
+	}
+	msg := LoadActivity{Offset: m.more.next, N: min(UserPage, len(m.user.Submitted)-m.more.next)}
+	m.more.start(msg.N)
+	return m, func() tea.Msg { return msg }
+}
+
+// nearEnd reports whether the tab shown has fewer than a screenful left
+// below the cursor.
+func (m UserModel) nearEnd() bool {
+	t := m.tabs[m.tab]
+	return t.cursor >= len(t.items)-max(moreMargin, m.visibleItems())
+}
+
+// about renders the user's about text, or "" if there is none.
+func (m UserModel) about() string {
+	if m.user == nil || m.user.About == "" {
+		return ""
+	}
+	return wrapText(util.StripHTML(m.user.About), m.width-4, "  ") + "\n\n"
+}
+
+// visibleItems is how many submissions fit below the profile and the tab
+// bar, at three lines each.
+func (m UserModel) visibleItems() int {
+	return max(1, (m.height-9-strings.Count(m.about(), "\n"))/3)
+}
+
 func (m UserModel) View() string {
 	var b strings.Builder
 
@@ -74,7 +222,7 @@ func (m UserModel) View() string {
 	if m.user != nil {
 		title = "User: " + m.user.ID
 	}
-	b.WriteString(HeaderStyle.Width(m.width).Render("  " + title))
+	b.WriteString(HeaderStyle.Width(m.width).Render("  " + withCrumbs(m.crumbs, title)))
 	b.WriteString("\n\n")
 
 	if m.loading {
@@ -91,42 +239,80 @@ func (m UserModel) View() string {
 	}
 
 	u := m.user
-	b.WriteString(fmt.Sprintf("  %s  %s  karma: %s  joined: %s\n\n",
+	b.WriteString(fmt.Sprintf("  %s  %s  karma: %s  joined: %s  %s  %d submissions\n\n",
 		UserNameStyle.Render(u.ID),
 		Sep(),
 		UserKarmaStyle.Render(fmt.Sprint(u.Karma)),
 		MetaStyle.Render(time.Unix(u.Created, 0).Format("Jan 2006")),
+		Sep(),
+		len(u.Submitted),
 	))
+	b.WriteString(m.about())
 
-	if u.About != "" {
-		b.WriteString(wrapText(util.StripHTML(u.About), m.width-4, "  ") + "\n\n")
+	// Tab bar, with how many of each kind have been found so far.
+	more := ""
+	if m.more.next < len(u.Submitted) {
+		more = "+"
 	}
+	var parts []string
+	for i, name := range activityNames {
+		label := fmt.Sprintf("%s %d%s", name, len(m.tabs[i].items), more)
+		if i == m.tab {
+			parts = append(parts, ActiveTabStyle.Render(label))
+		} else {
+			parts = append(parts, TabStyle.Render(label))
+		}
+	}
+	b.WriteString(" " + strings.Join(parts, "") + "\n\n")
 
-	if len(m.items) > 0 {
-		b.WriteString(TitleStyle.Render("  Recent submissions:") + "\n\n")
-		end := m.scroll + m.height - 8
-		if end > len(m.items) {
-			end = len(m.items)
+	t := m.tabs[m.tab]
+	end := min(t.scroll+m.visibleItems(), len(t.items))
+	for i := t.scroll; i < end; i++ {
+		item := t.items[i]
+		title := item.Title
+		if item.Type == "comment" {
+			title = "on: " + item.StoryTitle
+			if item.StoryTitle == "" {
+				title = "on: (unknown story)"
+			}
 		}
-		start := m.scroll
-		if start > len(m.items) {
-			start = len(m.items)
+		prefix, titleStr := "  ", TitleStyle.Render(title)
+		if i == t.cursor {
+			prefix = lipgloss.NewStyle().Foreground(theme.Accent).Render("▶ ")
+			titleStr = SelectedTitleStyle.Render(title)
 		}
-		for i, item := range m.items[start:end] {
-			if item.Title != "" {
-				b.WriteString(fmt.Sprintf("  %s %s\n",
-					IndexStyle.Render(fmt.Sprintf("%d.", start+i+1)),
-					TitleStyle.Render(item.Title),
-				))
-				b.WriteString(fmt.Sprintf("     %s%s%s\n\n",
-					MetaStyle.Render(fmt.Sprintf("▲ %d · %s comments · %s", item.Score, commentsStr(item.Descendants), item.Age())),
-					Sep(),
-					URLStyle.Render(hostname(item.URL)),
-				))
-			}
+		b.WriteString(fmt.Sprintf("%s%s %s\n",
+			prefix,
+			IndexStyle.Render(fmt.Sprintf("%d.", i+1)),
+			titleStr,
+		))
+		if item.Type == "comment" {
+			metaText := item.Age() + " · "
+			excerpt := strings.Join(strings.Fields(util.StripHTML(item.Text)), " ")
+			b.WriteString("     " + MetaStyle.Render(metaText+util.Truncate(excerpt, max(10, m.width-len(metaText)-8))) + "\n\n")
+			continue
 		}
+		b.WriteString(fmt.Sprintf("     %s%s%s\n\n",
+			MetaStyle.Render(fmt.Sprintf("▲ %d · %s comments · %s", item.Score, commentsStr(item.Descendants), item.Age())),
+			Sep(),
+			URLStyle.Render(util.Hostname(item.URL)),
+		))
+	}
+
+	switch status := m.more.status(len(u.Submitted), "submissions", m.nearEnd()); {
+	case status != "":
+		b.WriteString(StatusStyle.Render(status) + "\n")
+	case len(t.items) == 0:
+		b.WriteString(StatusStyle.Render(fmt.Sprintf("  No %s.", strings.ToLower(activityNames[m.tab]))) + "\n")
+	default:
+		b.WriteString("\n")
 	}
 
-	b.WriteString(HelpStyle.Render("  ↑/↓ scroll · o: open in browser · r: refresh · ←/esc: back · q: quit"))
+	b.WriteString("\n")
+	k := keymap
+	b.WriteString(footer(m.width, "",
+		pair(k.Up, k.Down, "select"), bind(k.NextTab, "stories/comments/polls"), bind(k.Open, "open"),
+		bind(k.OpenHN, "open on hn"), bind(k.OpenURL, "open profile in browser"), bind(k.Refresh, "refresh"),
+		bind(k.Back, "back"), bind(k.Help, "help"), bind(k.Quit, "quit")))
 	return b.String()
 }
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hexadecimoose/hncli/internal/api"
+)
+
+// comments returns n comments with IDs counting down from id.
+func comments(id, n int) []*api.Item {
+	var items []*api.Item
+	for i := range n {
+		items = append(items, &api.Item{ID: id - i, Type: "comment"})
+	}
+	return items
+}
+
+func TestUserLoadMoreStopsOnEmptyPages(t *testing.T) {
+	user := &api.User{ID: "pg"}
+	for id := 1000; id > 1000-5*UserPage; id-- {
+		user.Submitted = append(user.Submitted, id)
+	}
+	m := NewUserModel()
+
+	// Only comments so far, so the stories tab stays empty: no more is
+	// fetched until asked for.
+	m, cmd := m.Update(UserLoaded{User: user, Items: comments(1000, UserPage)})
+	if cmd != nil {
+		t.Fatalf("UserLoaded with nothing for the tab shown asked for %v", cmd())
+	}
+	if v := m.View(); !strings.Contains(v, "30 of 150 submissions looked through") {
+		t.Errorf("view = %q, want a hint that there is more to look through", v)
+	}
+
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if cmd == nil {
+		t.Fatal("down on an empty tab did not load more")
+	}
+	if msg := cmd().(LoadActivity); msg.Offset != UserPage || msg.N != UserPage {
+		t.Errorf("down asked for %+v, want the next page", msg)
+	}
+	m, cmd = m.Update(ActivityLoaded{Items: comments(1000-UserPage, UserPage)})
+	if cmd != nil {
+		t.Errorf("a second page with nothing for the tab asked for %v", cmd())
+	}
+
+	// A page that adds to the tab is followed by the next one.
+	items := comments(1000-2*UserPage, UserPage)
+	items[0].Type = "story"
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, cmd = m.Update(ActivityLoaded{Items: items})
+	if cmd == nil {
+		t.Fatal("a page that added a story did not load the next")
+	}
+	if msg := cmd().(LoadActivity); msg.Offset != 3*UserPage {
+		t.Errorf("after the third page asked for %+v, want offset %d", msg, 3*UserPage)
+	}
+	if n := len(m.tabs[tabStories].items); n != 1 {
+		t.Errorf("stories tab has %d items, want 1", n)
+	}
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"math"
+	"strings"
+)
+
+// Bar draws n out of total as a horizontal bar width cells wide, using
+// eighth blocks for the partly filled cell and light shade for the rest.
+func Bar(n, total, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	eighths := 0
+	if total > 0 && n > 0 {
+		eighths = int(math.Round(float64(min(n, total)) / float64(total) * float64(width*8)))
+	}
+	full, part := eighths/8, eighths%8
+	var b strings.Builder
+	b.WriteString(strings.Repeat("█", full))
+	if part > 0 {
+		b.WriteString(string([]rune("▏▎▍▌▋▊▉")[part-1]))
+		full++
+	}
+	b.WriteString(strings.Repeat("░", width-full))
+	return b.String()
+}
+
+// Percent returns n as a whole percentage of total, or 0 if total is 0.
+func Percent(n, total int) int {
+	if total <= 0 {
+		return 0
+	}
+	return int(math.Round(float64(n) * 100 / float64(total)))
+}
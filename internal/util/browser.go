@@ -1,13 +1,19 @@
 package util
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 )
 
-// OpenBrowser opens url using HNCLI_OPEN if set, otherwise the system browser.
+// OpenCommand is used like HNCLI_OPEN when that is not set, e.g. from a
+// config file.
+var OpenCommand string
+
+// OpenBrowser opens url using HNCLI_OPEN or OpenCommand if set, otherwise
+// the system browser.
 //
 // HNCLI_OPEN is treated as a sh -c command. Use {} as a placeholder for the
 // URL; if absent the URL is appended as the last argument. Examples:
@@ -17,18 +23,33 @@ import (
 //	HNCLI_OPEN="echo {} | wl-copy"                 # Wayland clipboard
 //	HNCLI_OPEN="echo {} | pbcopy"                  # macOS clipboard
 func OpenBrowser(url string) error {
-	if tmpl := os.Getenv("HNCLI_OPEN"); tmpl != "" {
-		var sh string
-		if strings.Contains(tmpl, "{}") {
-			sh = strings.ReplaceAll(tmpl, "{}", url)
-		} else {
-			sh = tmpl + " " + url
-		}
-		return exec.Command("sh", "-c", sh).Start()
+	tmpl := os.Getenv("HNCLI_OPEN")
+	if tmpl == "" {
+		tmpl = OpenCommand
+	}
+	if tmpl != "" {
+		return ShellCommand(tmpl, url).Start()
 	}
 	return openDefault(url)
 }
 
+// ShellCommand returns a sh -c command for the template tmpl, with {}
+// replaced by arg, or arg appended as the last argument if there is no {}.
+// arg is not quoted, so it must be safe to pass to the shell as is.
+func ShellCommand(tmpl, arg string) *exec.Cmd {
+	return ShellCommandContext(context.Background(), tmpl, arg)
+}
+
+// ShellCommandContext is like ShellCommand but the command is killed when
+// ctx is done.
+func ShellCommandContext(ctx context.Context, tmpl, arg string) *exec.Cmd {
+	sh := tmpl + " " + arg
+	if strings.Contains(tmpl, "{}") {
+		sh = strings.ReplaceAll(tmpl, "{}", arg)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", sh)
+}
+
 // openDefault opens url in the system default browser.
 func openDefault(url string) error {
 	var cmd string
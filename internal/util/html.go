@@ -4,6 +4,7 @@ import (
 	"html"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 var (
@@ -23,3 +24,13 @@ func StripHTML(s string) string {
 	s = html.UnescapeString(s)
 	return strings.TrimSpace(s)
 }
+
+// Truncate shortens s to at most n characters, ending with "…" if it was
+// cut. n <= 0 leaves s as it is.
+func Truncate(s string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// Notify shows a desktop notification, using notify-send on Linux and the
+// BSDs, osascript on macOS and a msg popup on Windows. title and body are
+// passed as arguments, not through a shell, so they need no quoting.
+func Notify(title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, body)
+	case "windows":
+		cmd = exec.Command("msg", "*", title+"\n"+body)
+	default:
+		cmd = exec.Command("notify-send", "--app-name=hncli", title, body)
+	}
+	return cmd.Run()
+}
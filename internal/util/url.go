@@ -0,0 +1,18 @@
+package util
+
+import "strings"
+
+// Hostname returns the host part of rawURL without scheme or "www." prefix,
+// for compact display next to a story title.
+func Hostname(rawURL string) string {
+	// Trim scheme and www.
+	s := rawURL
+	for _, prefix := range []string{"https://", "http://"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	s = strings.TrimPrefix(s, "www.")
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}